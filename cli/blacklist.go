@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"github.com/weynsee/go-phrase/phrase"
+	"regexp"
+	"strings"
+)
+
+// blacklist matches the keys PhraseApp ignores. Like PhraseApp, patterns
+// match whole keys, and * is the only wildcard, matching any characters,
+// dots included: date.* matches date.formats.short but not dates.
+type blacklist struct {
+	patterns []string
+	exprs    []*regexp.Regexp
+}
+
+func newBlacklist(patterns []string) *blacklist {
+	b := &blacklist{patterns: patterns, exprs: make([]*regexp.Regexp, len(patterns))}
+	for i, pattern := range patterns {
+		expr := strings.Replace(regexp.QuoteMeta(pattern), `\*`, ".*", -1)
+		b.exprs[i] = regexp.MustCompile(`\A` + expr + `\z`)
+	}
+	return b
+}
+
+// fetchBlacklist returns the blacklist of the project.
+func fetchBlacklist(api *phrase.Client) (*blacklist, error) {
+	patterns, err := api.Blacklist.Keys()
+	if err != nil {
+		return nil, err
+	}
+	return newBlacklist(patterns), nil
+}
+
+// match returns the first pattern that matches key.
+func (b *blacklist) match(key string) (string, bool) {
+	for i, expr := range b.exprs {
+		if expr.MatchString(key) {
+			return b.patterns[i], true
+		}
+	}
+	return "", false
+}
+
+// keys returns the keys of the messages of cat that are blacklisted.
+func (b *blacklist) keys(cat *catalog) []string {
+	var keys []string
+	for _, m := range cat.Messages {
+		if _, ok := b.match(m.Key); ok && m.Key != "" {
+			keys = append(keys, m.Key)
+		}
+	}
+	return keys
+}
+
+// filter removes the blacklisted keys from the content of a file of the
+// format, and returns the keys it removed. The content is only uploaded, so
+// it is written by the codec of the format, which may not keep its layout.
+// Content that the format has no codec for, or that the codec cannot read,
+// is returned as it is, for PhraseApp to deal with. Nothing is read with an
+// empty blacklist.
+func (b *blacklist) filter(format, content string) (string, []string, error) {
+	f, ok := formats[format]
+	if len(b.exprs) == 0 || !ok || f.properties().codec == nil {
+		return content, nil, nil
+	}
+	codec := f.properties().codec
+	cat, err := codec.decode([]byte(content))
+	if err != nil {
+		return content, nil, nil
+	}
+	removed := b.keys(cat)
+	if len(removed) == 0 {
+		return content, nil, nil
+	}
+	kept := cat.Messages[:0]
+	for _, m := range cat.Messages {
+		if _, ok := b.match(m.Key); !ok || m.Key == "" {
+			kept = append(kept, m)
+		}
+	}
+	cat.Messages = kept
+	out, err := codec.encode(cat)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(out), removed, nil
+}
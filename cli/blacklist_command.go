@@ -0,0 +1,240 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	mcli "github.com/mitchellh/cli"
+	"github.com/weynsee/go-phrase/phrase"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BlacklistCommand will display the keys PhraseApp ignores, e.g. to debug
+// keys missing after a push.
+type BlacklistCommand struct {
+	UI     mcli.Ui
+	Config *Config
+	API    *phrase.Client
+}
+
+// Run executes the blacklist command.
+func (c *BlacklistCommand) Run(args []string) int {
+	cmdFlags := flag.NewFlagSet("blacklist", flag.ContinueOnError)
+	cmdFlags.Usage = func() { c.UI.Output(c.Help()) }
+	cmdFlags.StringVar(&c.Config.Secret, "secret", c.Config.Secret, "")
+	var format string
+	cmdFlags.StringVar(&format, "format", "", "")
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+	args = cmdFlags.Args()
+	if len(args) == 0 {
+		c.UI.Output(c.Help())
+		return 1
+	}
+
+	// options may follow the subcommand as well
+	subcommand := args[0]
+	subFlags := flag.NewFlagSet("blacklist "+subcommand, flag.ContinueOnError)
+	subFlags.Usage = func() { c.UI.Output(c.Help()) }
+	subFlags.StringVar(&c.Config.Secret, "secret", c.Config.Secret, "")
+	if subcommand == "scan" {
+		subFlags.StringVar(&format, "format", format, "")
+	}
+	if err := subFlags.Parse(args[1:]); err != nil {
+		return 1
+	}
+	args = subFlags.Args()
+	if subcommand != "list" && len(args) == 0 {
+		c.UI.Output(c.Help())
+		return 1
+	}
+	if _, found := formats[format]; format != "" && !found {
+		c.UI.Error("Unrecognized format: " + format)
+		return 1
+	}
+
+	if err := c.Config.loadSecret(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	c.API.AuthToken = c.Config.Secret
+	b, err := fetchBlacklist(c.API)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error encountered while fetching the blacklisted keys: %s", err.Error()))
+		return 1
+	}
+	switch subcommand {
+	case "list":
+		return c.list(b)
+	case "check":
+		return c.check(b, args)
+	case "scan":
+		return c.scan(b, args, format)
+	}
+	c.UI.Output(c.Help())
+	return 1
+}
+
+func (c *BlacklistCommand) list(b *blacklist) int {
+	for _, pattern := range b.patterns {
+		c.UI.Output(pattern)
+	}
+	return 0
+}
+
+// check reports whether each key is blacklisted. It fails if any is.
+func (c *BlacklistCommand) check(b *blacklist, keys []string) int {
+	status := 0
+	for _, key := range keys {
+		if pattern, ok := b.match(key); ok {
+			c.UI.Output(fmt.Sprintf("%s is blacklisted by %s", key, pattern))
+			status = 1
+		} else {
+			c.UI.Output(fmt.Sprintf("%s is not blacklisted", key))
+		}
+	}
+	return status
+}
+
+// scan reports the keys of the files that PhraseApp would ignore.
+// Directories are scanned recursively for locale files, skipping hidden
+// directories and directories of dependencies.
+func (c *BlacklistCommand) scan(b *blacklist, paths []string, format string) int {
+	var files []string
+	// the format of the files of sources is the one of their source
+	formatOf := make(map[string]string)
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error reading %s:\n\t%s", path, err.Error()))
+			return 1
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			formatOf[path] = format
+			continue
+		}
+		dir := path
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if path != dir && ignoredDirectory(info.Name()) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if fileFormat, ok := c.localeFile(dir, path, format); ok {
+				files = append(files, path)
+				formatOf[path] = fileFormat
+			}
+			return nil
+		})
+	}
+
+	var found, scanned int
+	for _, file := range files {
+		keys, err := c.scanFile(b, file, formatOf[file])
+		if err != nil {
+			c.UI.Warn(fmt.Sprintf("Skipping %s: %s", file, err.Error()))
+			continue
+		}
+		scanned++
+		for _, key := range keys {
+			pattern, _ := b.match(key)
+			c.UI.Output(fmt.Sprintf("%s: %s (blacklisted by %s)", file, key, pattern))
+		}
+		found += len(keys)
+	}
+	c.UI.Info(fmt.Sprintf("%d key(s) of %d file(s) would be ignored by PhraseApp", found, scanned))
+	return 0
+}
+
+// localeFile returns whether a file found in dir is a locale file, and its
+// format if none is given: one of the files of the configured sources, with
+// the format of its source, or of the configured locale directory and
+// filename, or else one whose path tells a locale.
+func (c *BlacklistCommand) localeFile(dir, file, format string) (string, bool) {
+	config := c.Config
+	if len(config.Sources) > 0 {
+		abs, _ := filepath.Abs(file)
+		for _, source := range config.Sources {
+			pattern, _ := filepath.Abs(config.resolve(source.glob()))
+			if ok, _ := filepath.Match(pattern, abs); ok {
+				if format == "" {
+					format = source.Format
+				}
+				return format, true
+			}
+		}
+		return "", false
+	}
+	if config.LocaleDirectory != "" || config.LocaleFilename != "" {
+		if format == "" {
+			format = guessFormatFromFileExtension(file)
+		}
+		locale, _ := config.localeFromPath(file, format)
+		return format, locale != ""
+	}
+	return format, localeInPath(dir, file)
+}
+
+// scanFile returns the blacklisted keys of a file.
+func (c *BlacklistCommand) scanFile(b *blacklist, file, format string) ([]string, error) {
+	if format == "" {
+		format = guessFormatFromFileExtension(file)
+	}
+	f, ok := formats[format]
+	if !ok || f.properties().codec == nil {
+		return nil, fmt.Errorf("Cannot read the keys of %s files", format)
+	}
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	hint, err := encodingHint(c.Config.encodingForFormat(format), format)
+	if err != nil {
+		return nil, err
+	}
+	text, _, err := decodeText(content, hint)
+	if err != nil {
+		return nil, err
+	}
+	cat, err := f.properties().codec.decode([]byte(text))
+	if err != nil {
+		return nil, err
+	}
+	return b.keys(cat), nil
+}
+
+// Help displays available options for the blacklist command.
+func (c *BlacklistCommand) Help() string {
+	helpText := `
+	Usage: phrase blacklist [options] list|check KEY...|scan FILE...
+
+	  Display the keys PhraseApp ignores. Patterns match whole keys, with * matching
+	  any characters, dots included.
+
+	  list          List the blacklisted key patterns of the project
+	  check KEY...  Tell whether the keys are blacklisted, failing if any is
+	  scan FILE...  Report the keys of locale files, or of the locale files in
+	                directories, that PhraseApp would ignore. Files of directories
+	                count if they match the configured sources or locale paths, or
+	                else if their path tells a locale
+
+	Options:
+
+	  --format=yml              The format of the scanned files, guessed from their extension by default
+	  --secret=YOUR_AUTH_TOKEN  The Auth Token to use for this operation instead of the saved one (optional)
+	`
+	return strings.TrimSpace(helpText)
+}
+
+// Synopsis displays a synopsis of the blacklist command.
+func (c *BlacklistCommand) Synopsis() string {
+	return "Check keys and locale files against the blacklisted keys"
+}
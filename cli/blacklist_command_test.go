@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"fmt"
+	mcli "github.com/mitchellh/cli"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func setupBlacklist() {
+	setupAPI()
+	mux.HandleFunc("/blacklisted_keys", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"name":"debug.*"},{"name":"*.internal"}]`)
+	})
+}
+
+func TestBlacklistCommand_list(t *testing.T) {
+	setupBlacklist()
+	defer shutdownAPI()
+
+	ui := new(mcli.MockUi)
+	c := &BlacklistCommand{UI: ui, Config: new(Config), API: client}
+	if code := c.Run([]string{"list"}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	if out, want := ui.OutputWriter.String(), "debug.*\n*.internal\n"; out != want {
+		t.Errorf("Blacklist list should display the patterns, expected %q, got %q", want, out)
+	}
+}
+
+func TestBlacklistCommand_check(t *testing.T) {
+	setupBlacklist()
+	defer shutdownAPI()
+
+	ui := new(mcli.MockUi)
+	c := &BlacklistCommand{UI: ui, Config: new(Config), API: client}
+	if code := c.Run([]string{"check", "menu.title", "menu.internal"}); code != 1 {
+		t.Errorf("Blacklist check should fail when a key is blacklisted, got %d", code)
+	}
+	want := "menu.title is not blacklisted\nmenu.internal is blacklisted by *.internal\n"
+	if out := ui.OutputWriter.String(); out != want {
+		t.Errorf("Blacklist check should display the keys, expected %q, got %q", want, out)
+	}
+
+	ui = new(mcli.MockUi)
+	c = &BlacklistCommand{UI: ui, Config: new(Config), API: client}
+	if code := c.Run([]string{"check", "menu.title"}); code != 0 {
+		t.Errorf("Blacklist check should succeed when no key is blacklisted, got %d", code)
+	}
+}
+
+func TestBlacklistCommand_scan(t *testing.T) {
+	setupBlacklist()
+	defer tearDown()
+
+	createTestFiles(map[string][]byte{
+		"en.yml":    []byte("en:\n  title: Title\n  debug:\n    token: abc\n"),
+		"de.yml":    []byte("de:\n  title: Titel\n"),
+		"en.php":    []byte("<?php"),
+		"readme.md": []byte("# Locales"),
+	})
+
+	ui := new(mcli.MockUi)
+	c := &BlacklistCommand{UI: ui, Config: new(Config), API: client}
+	if code := c.Run([]string{"scan", testFolder}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	out := ui.OutputWriter.String()
+	if want := filepath.Join(testFolder, "en.yml") + ": debug.token (blacklisted by debug.*)"; strings.Index(out, want) == -1 {
+		t.Errorf("Blacklist scan should report blacklisted keys, expected %q, got %q", want, out)
+	}
+	if want := "1 key(s) of 2 file(s) would be ignored by PhraseApp"; strings.Index(out, want) == -1 {
+		t.Errorf("Blacklist scan should summarize, expected %q, got %q", want, out)
+	}
+	if err := ui.ErrorWriter.String(); strings.Index(err, "Skipping "+filepath.Join(testFolder, "en.php")) == -1 {
+		t.Errorf("Blacklist scan should skip files it cannot read, got %q", err)
+	}
+}
+
+func TestBlacklistCommand_scanLocaleFiles(t *testing.T) {
+	setupBlacklist()
+	defer tearDown()
+
+	createTestFiles(map[string][]byte{
+		"en.yml":   []byte("en:\n  debug:\n    token: abc\n"),
+		"app.json": []byte(`{"debug": {"token": "abc"}}`),
+	})
+	prepareLocaleFiles(map[string][]byte{"en.yml": []byte("en:\n  debug:\n    token: abc\n")}, testFolder, "node_modules", "dep")
+	prepareLocaleFiles(map[string][]byte{"en.yml": []byte("en:\n  debug:\n    token: abc\n")}, testFolder, ".cache")
+
+	ui := new(mcli.MockUi)
+	c := &BlacklistCommand{UI: ui, Config: new(Config), API: client}
+	if code := c.Run([]string{"scan", "--format=yml", testFolder}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); strings.Index(out, "1 key(s) of 1 file(s) would be ignored by PhraseApp") == -1 {
+		t.Errorf("Blacklist scan should only scan locale files out of ignored directories, got %q", out)
+	}
+
+	ui = new(mcli.MockUi)
+	config := &Config{Sources: []*SourceConfig{{Files: filepath.Join(testFolder, "*.json"), Format: "nested_json"}}}
+	c = &BlacklistCommand{UI: ui, Config: config, API: client}
+	if code := c.Run([]string{"scan", testFolder}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	out := ui.OutputWriter.String()
+	if want := filepath.Join(testFolder, "app.json") + ": debug.token"; strings.Index(out, want) == -1 || strings.Index(out, "of 1 file(s)") == -1 {
+		t.Errorf("Blacklist scan should scan the files of the configured sources, got %q", out)
+	}
+}
+
+func TestBlacklistCommand_usage(t *testing.T) {
+	ui := new(mcli.MockUi)
+	c := &BlacklistCommand{UI: ui, Config: new(Config)}
+	for _, args := range [][]string{{}, {"check"}, {"scan"}} {
+		if code := c.Run(args); code != 1 {
+			t.Errorf("Blacklist %v should fail", args)
+		}
+	}
+}
+
+func TestBlacklistCommand_error(t *testing.T) {
+	setupAPI()
+	defer shutdownAPI()
+
+	mux.HandleFunc("/blacklisted_keys", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(503)
+	})
+
+	ui := new(mcli.MockUi)
+	c := &BlacklistCommand{UI: ui, Config: new(Config), API: client}
+	if code := c.Run([]string{"list"}); code == 0 {
+		t.Fatal("API error should return code != 0")
+	}
+	if err := ui.ErrorWriter.String(); strings.Index(err, "Error encountered") == -1 {
+		t.Fatal("UI should display error message")
+	}
+}
+
+func TestBlacklistCommand_Help(t *testing.T) {
+	c := BlacklistCommand{}
+	if c.Help() == "" {
+		t.Fatal("Help should not be empty")
+	}
+}
+
+func TestBlacklistCommand_Synopsis(t *testing.T) {
+	c := BlacklistCommand{}
+	if c.Synopsis() == "" {
+		t.Fatal("Synopsis should not be empty")
+	}
+}
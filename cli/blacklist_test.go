@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBlacklist_match(t *testing.T) {
+	b := newBlacklist([]string{"date.*", "*.internal", "debug", "a+b"})
+	tests := map[string]bool{
+		"date.formats.short": true,
+		"date.":              true,
+		"dates":              false,
+		"date":               false,
+		"menu.internal":      true,
+		"menu.internals":     false,
+		"debug":              true,
+		"debugger":           false,
+		"a+b":                true,
+		"aab":                false,
+	}
+	for key, want := range tests {
+		if _, got := b.match(key); got != want {
+			t.Errorf("match(%q) returned %v, want %v", key, got, want)
+		}
+	}
+	if pattern, _ := b.match("date.today"); pattern != "date.*" {
+		t.Errorf("match should return the pattern that matched, got %q", pattern)
+	}
+}
+
+func TestBlacklist_filter(t *testing.T) {
+	b := newBlacklist([]string{"debug.*"})
+	content, removed, err := b.filter("yml", "en:\n  title: Title\n  debug:\n    token: abc\n    level: 2\n")
+	if err != nil {
+		t.Fatalf("filter returned error: %v", err)
+	}
+	if want := []string{"debug.token", "debug.level"}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("filter should return the removed keys, expected %v, got %v", want, removed)
+	}
+	if want := "en:\n  title: Title\n"; content != want {
+		t.Errorf("filter should remove blacklisted keys, expected %q, got %q", want, content)
+	}
+
+	unchanged := "en:\n  title: Title # kept as it is\n"
+	if content, removed, _ = b.filter("yml", unchanged); content != unchanged || removed != nil {
+		t.Errorf("filter should not touch content without blacklisted keys, got %q", content)
+	}
+	if content, _, _ = b.filter("php_array", "<?php debug.token"); content != "<?php debug.token" {
+		t.Errorf("filter should not touch formats without codec, got %q", content)
+	}
+
+	// files are stripped even if the codec changes their layout
+	indented := "{\n    \"title\": \"Title\",\n    \"debug\": {\n        \"token\": \"abc\"\n    }\n}\n"
+	if content, removed, _ = b.filter("nested_json", indented); strings.Contains(content, "token") || len(removed) != 1 {
+		t.Errorf("filter should remove blacklisted keys of files it writes differently, got %q", content)
+	}
+
+	if content, removed, _ = newBlacklist(nil).filter("yml", "en: [unreadable"); content != "en: [unreadable" || removed != nil {
+		t.Errorf("filter should not read content with an empty blacklist, got %q", content)
+	}
+}
@@ -2,17 +2,35 @@ package cli
 
 import (
 	mcli "github.com/mitchellh/cli"
+	"os"
+	"strings"
 )
 
 type cli struct {
 	cli *mcli.CLI
 }
 
-// NewCLI returns a CLI instance.
+// NewCLI returns a CLI instance. The config file is the one given with
+// --config PATH, or the first one found from the current directory up to
+// the root of the repository, in which case paths in it are relative to
+// its directory. --profile NAME selects a profile of the config, instead
+// of the one in PHRASE_PROFILE.
 func NewCLI(version string, args []string) *cli {
 	c := mcli.NewCLI("go-phrase", version)
-	c.Args = args
 	c.Commands = commands
+	args, path := globalFlag(args, "config")
+	args, profile := globalFlag(args, "profile")
+	if path != "" || profile != "" {
+		root := ""
+		if path == "" {
+			path, root = defaultConfig()
+		}
+		if profile == "" {
+			profile = os.Getenv("PHRASE_PROFILE")
+		}
+		c.Commands = newCommands(path, root, profile)
+	}
+	c.Args = args
 
 	return &cli{c}
 }
@@ -21,3 +39,29 @@ func NewCLI(version string, args []string) *cli {
 func (c *cli) Run() (int, error) {
 	return c.cli.Run()
 }
+
+// globalFlag removes the flag with the given name from args, wherever it
+// is, and returns its value.
+func globalFlag(args []string, name string) ([]string, string) {
+	var value string
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		switch {
+		case (arg == "--"+name || arg == "-"+name) && i+1 < len(args):
+			value = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--"+name+"="):
+			value = strings.TrimPrefix(arg, "--"+name+"=")
+		case strings.HasPrefix(arg, "-"+name+"="):
+			value = strings.TrimPrefix(arg, "-"+name+"=")
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return rest, value
+}
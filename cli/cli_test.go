@@ -34,3 +34,25 @@ func TestNewCLI_Run(t *testing.T) {
 		t.Fatal("NewCLI Run exit code should be 0")
 	}
 }
+
+func TestGlobalFlag(t *testing.T) {
+	tests := []struct {
+		args []string
+		rest []string
+		path string
+	}{
+		{[]string{"--config", "ci.phrase.yml", "pull"}, []string{"pull"}, "ci.phrase.yml"},
+		{[]string{"push", "--config=ci.phrase.yml", "--dry-run"}, []string{"push", "--dry-run"}, "ci.phrase.yml"},
+		{[]string{"pull", "-config", "a.phrase"}, []string{"pull"}, "a.phrase"},
+		{[]string{"push", "--", "--config"}, []string{"push", "--", "--config"}, ""},
+		{[]string{"push"}, []string{"push"}, ""},
+		{[]string{"--profile=staging", "pull", "--config", "a.phrase"}, []string{"pull"}, "a.phrase"},
+	}
+	for _, test := range tests {
+		rest, path := globalFlag(test.args, "config")
+		rest, _ = globalFlag(rest, "profile")
+		if !reflect.DeepEqual(rest, test.rest) || path != test.path {
+			t.Errorf("globalFlag(%q) returned %q, %q, want %q, %q", test.args, rest, path, test.rest, test.path)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// arbCodec reads and writes Application Resource Bundle files, as used by
+// Flutter. Messages are ICU message strings. The @key metadata of a message
+// is kept as it is, with its description also read as the description of
+// the message. Global @@ attributes are kept as untranslatable messages,
+// and @@locale is read as the locale of the catalog.
+type arbCodec struct{}
+
+func (c *arbCodec) decode(b []byte) (*catalog, error) {
+	root, err := decodeJSONObject(b)
+	if err != nil {
+		return nil, err
+	}
+	cat := new(catalog)
+	byKey := make(map[string]*message)
+	for i, key := range root.keys {
+		value := root.values[i]
+		if strings.HasPrefix(key, "@") && !strings.HasPrefix(key, "@@") {
+			continue
+		}
+		m := &message{Key: key}
+		if strings.HasPrefix(key, "@@") {
+			m.Flags = []string{untranslatableFlag}
+			if value.kind != jsonScalar {
+				m.Metadata = value
+			}
+			if key == "@@locale" {
+				cat.Locale = value.scalar
+			}
+		} else if value.kind != jsonScalar {
+			return nil, fmt.Errorf("Value of key %s must be a string", key)
+		}
+		m.Value = value.scalar
+		byKey[key] = m
+		cat.Messages = append(cat.Messages, m)
+	}
+
+	for i, key := range root.keys {
+		if !strings.HasPrefix(key, "@") || strings.HasPrefix(key, "@@") {
+			continue
+		}
+		value := root.values[i]
+		m, ok := byKey[key[1:]]
+		if !ok {
+			// metadata without a message is kept where it is
+			m = &message{Key: key, Flags: []string{untranslatableFlag}, Metadata: value}
+			cat.Messages = append(cat.Messages, m)
+			continue
+		}
+		if value.kind != jsonObject {
+			return nil, fmt.Errorf("Metadata %s must be an object", key)
+		}
+		m.Metadata = value
+		if description := value.get("description"); description != nil {
+			m.Description = description.scalar
+		}
+	}
+	return cat, nil
+}
+
+func (c *arbCodec) encode(cat *catalog) ([]byte, error) {
+	root := &jsonValue{kind: jsonObject}
+	if cat.Locale != "" && cat.find("@@locale") == nil {
+		root.set("@@locale", &jsonValue{scalar: cat.Locale})
+	}
+	for _, m := range cat.Messages {
+		if m.hasFlag(untranslatableFlag) {
+			value := m.Metadata
+			if value == nil {
+				value = &jsonValue{scalar: m.Value}
+				if m.Key == "@@locale" && cat.Locale != "" {
+					value.scalar = cat.Locale
+				}
+			}
+			root.set(m.Key, value)
+			continue
+		}
+		root.set(m.Key, &jsonValue{scalar: m.Value})
+		if metadata := c.metadata(m); metadata != nil {
+			root.set("@"+m.Key, metadata)
+		}
+	}
+	var buf bytes.Buffer
+	writeJSONValue(&buf, root, 1)
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// metadata returns the @key object of a message, updated with its
+// description, or nil if the message has none.
+func (c *arbCodec) metadata(m *message) *jsonValue {
+	metadata := m.Metadata
+	if metadata == nil {
+		if m.Description == "" {
+			return nil
+		}
+		metadata = &jsonValue{kind: jsonObject}
+	}
+	if m.Description != "" {
+		metadata.set("description", &jsonValue{scalar: m.Description})
+	} else {
+		metadata.remove("description")
+	}
+	return metadata
+}
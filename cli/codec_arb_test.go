@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"testing"
+)
+
+func TestArbCodec_roundTrip(t *testing.T) {
+	content := `{
+  "@@locale": "en",
+  "@@last_modified": "2020-01-01T00:00:00Z",
+  "title": "Hello World",
+  "@title": {
+    "description": "The title of the app"
+  },
+  "itemCount": "{count, plural, =0{No items} =1{1 item} other{{count} items}}",
+  "@itemCount": {
+    "description": "Number of items",
+    "placeholders": {
+      "count": {
+        "type": "int",
+        "example": 42,
+        "optionalParameters": {
+          "decimalDigits": 0
+        }
+      }
+    }
+  },
+  "plain": "No metadata"
+}
+`
+	cat := testRoundTrip(t, &arbCodec{}, content)
+	if cat.Locale != "en" {
+		t.Errorf("decode expected locale en, got %q", cat.Locale)
+	}
+	if len(cat.Messages) != 5 {
+		t.Fatalf("decode expected 5 messages, got %d", len(cat.Messages))
+	}
+	if m := cat.Messages[2]; m.Key != "title" || m.Value != "Hello World" || m.Description != "The title of the app" {
+		t.Errorf("decode returned unexpected message %+v", m)
+	}
+	keys := cat.flatten()
+	if _, ok := keys["@@locale"]; ok || len(keys) != 3 {
+		t.Errorf("flatten should only return messages, got %v", keys)
+	}
+}
+
+func TestArbCodec_encode(t *testing.T) {
+	cat := &catalog{Locale: "de", Messages: []*message{
+		{Key: "title", Value: "Hallo", Description: "Title"},
+		{Key: "orphan"},
+	}}
+	b, err := (&arbCodec{}).encode(cat)
+	if err != nil {
+		t.Fatalf("encode returned error %v", err)
+	}
+	want := `{
+  "@@locale": "de",
+  "title": "Hallo",
+  "@title": {
+    "description": "Title"
+  },
+  "orphan": ""
+}
+`
+	if string(b) != want {
+		t.Errorf("encode expected %s, got %s", want, b)
+	}
+}
+
+func TestArbCodec_error(t *testing.T) {
+	for _, content := range []string{`{"a": {"b": "c"}}`, `{"a": "b", "@a": "c"}`, `[]`} {
+		if _, err := (&arbCodec{}).decode([]byte(content)); err == nil {
+			t.Errorf("decode should return an error for %s", content)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// fluentCodec reads and writes Project Fluent (.ftl) files. Messages and
+// terms are messages of the catalog, their attributes are messages whose
+// key is the id of the message and the name of the attribute joined by a
+// dot, which Fluent ids cannot contain. Values that are a single select
+// expression on a variable with plural categories as variants are read as
+// plural forms, with the variable kept as the plural key of the message.
+// All other values, including term references like { -brand-name }, are
+// kept as they are written.
+//
+// Comments preceding a message are kept as its comment. Group, resource
+// and standalone comments are kept as messages without a key.
+type fluentCodec struct{}
+
+var (
+	fluentEntry     = regexp.MustCompile(`^(-?[a-zA-Z][a-zA-Z0-9_-]*)[ \t]*=[ \t]*(.*)$`)
+	fluentAttribute = regexp.MustCompile(`^\.([a-zA-Z][a-zA-Z0-9_-]*)[ \t]*=[ \t]*(.*)$`)
+	fluentPlural    = regexp.MustCompile(`(?s)^\{\s*\$([a-zA-Z][a-zA-Z0-9_-]*)\s*->\s*(.*?)\s*\}$`)
+	fluentVariant   = regexp.MustCompile(`^\s*(\*?)\[\s*([a-zA-Z0-9_-]+)\s*\][ \t]?(.*)$`)
+)
+
+func (c *fluentCodec) decode(b []byte) (*catalog, error) {
+	lines := strings.Split(strings.Replace(string(b), "\r\n", "\n", -1), "\n")
+	cat := &catalog{}
+	var comment []string
+	flushComment := func() {
+		if len(comment) > 0 {
+			cat.Messages = append(cat.Messages, &message{Comment: strings.Join(comment, "\n"), Flags: []string{untranslatableFlag}})
+			comment = nil
+		}
+	}
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.TrimSpace(line) == "":
+			flushComment()
+		case strings.HasPrefix(line, "#"):
+			// group and resource comments never belong to a message
+			if len(comment) > 0 && fluentCommentLevel(comment[0]) != fluentCommentLevel(line) {
+				flushComment()
+			}
+			comment = append(comment, line)
+		default:
+			res := fluentEntry.FindStringSubmatch(line)
+			if res == nil {
+				return nil, fmt.Errorf("Invalid line %d: %s", i+1, line)
+			}
+			// the entry continues on indented lines
+			end := i + 1
+			for j := i + 1; j < len(lines); j++ {
+				if strings.TrimSpace(lines[j]) == "" {
+					continue
+				}
+				// closing braces of placeables need no indentation
+				if lines[j][0] != ' ' && lines[j][0] != '}' {
+					break
+				}
+				end = j + 1
+			}
+			messages := fluentMessages(res[1], res[2], lines[i+1:end])
+			if len(comment) > 0 && fluentCommentLevel(comment[0]) == 1 {
+				messages[0].Comment = fluentCommentText(comment)
+				comment = nil
+			}
+			flushComment()
+			cat.Messages = append(cat.Messages, messages...)
+			i = end - 1
+		}
+	}
+	flushComment()
+	return cat, nil
+}
+
+func fluentCommentLevel(line string) int {
+	return len(line) - len(strings.TrimLeft(line, "#"))
+}
+
+func fluentCommentText(lines []string) string {
+	text := make([]string, len(lines))
+	for i, line := range lines {
+		text[i] = strings.TrimPrefix(strings.TrimPrefix(line, "#"), " ")
+	}
+	return strings.Join(text, "\n")
+}
+
+// fluentMessages returns the message with the given id and a message for
+// every attribute of it. value is the text after the = of the entry, and
+// lines the indented lines that follow it.
+func fluentMessages(id, value string, lines []string) []*message {
+	lines = dedent(lines)
+	m := &message{Key: id}
+	messages := []*message{m}
+	current, text := m, []string{}
+	if value != "" {
+		text = append(text, value)
+	}
+	depth := strings.Count(value, "{") - strings.Count(value, "}")
+	for _, line := range lines {
+		if res := fluentAttribute.FindStringSubmatch(line); res != nil && depth == 0 {
+			setFluentValue(current, text)
+			current = &message{Key: id + "." + res[1]}
+			messages = append(messages, current)
+			text = nil
+			if res[2] != "" {
+				text = append(text, res[2])
+			}
+			depth = strings.Count(res[2], "{") - strings.Count(res[2], "}")
+			continue
+		}
+		text = append(text, line)
+		depth += strings.Count(line, "{") - strings.Count(line, "}")
+	}
+	setFluentValue(current, text)
+	if m.Value == "" && m.Plurals == nil && len(messages) > 1 {
+		// messages with only attributes have no value to translate
+		m.Flags = []string{untranslatableFlag}
+	}
+	return messages
+}
+
+func setFluentValue(m *message, lines []string) {
+	// blank lines at the end of a value are not part of it
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	value := strings.Join(dedent(lines), "\n")
+	if res := fluentPlural.FindStringSubmatch(value); res != nil {
+		if plurals, ok := fluentVariants(res[2]); ok {
+			m.KeyPlural, m.Plurals = res[1], plurals
+			return
+		}
+	}
+	m.Value = value
+}
+
+// fluentVariants returns the variants of a select expression, if all of
+// them are plural categories.
+func fluentVariants(s string) (map[string]string, bool) {
+	plurals := make(map[string]string)
+	var category string
+	for _, line := range strings.Split(s, "\n") {
+		if res := fluentVariant.FindStringSubmatch(line); res != nil {
+			category = res[2]
+			if !isPluralCategory(category) {
+				return nil, false
+			}
+			plurals[category] = res[3]
+		} else if category != "" {
+			plurals[category] += "\n" + strings.TrimSpace(line)
+		} else {
+			return nil, false
+		}
+	}
+	return plurals, len(plurals) > 0
+}
+
+// dedent removes the indentation all non-blank lines have in common.
+func dedent(lines []string) []string {
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if n := len(line) - len(strings.TrimLeft(line, " ")); indent == -1 || n < indent {
+			indent = n
+		}
+	}
+	res := make([]string, len(lines))
+	for i, line := range lines {
+		if len(line) >= indent && indent > 0 {
+			line = line[indent:]
+		}
+		res[i] = strings.TrimRight(line, " ")
+	}
+	return res
+}
+
+// encode writes attributes after the message they belong to, even if they
+// are not next to it in the catalog, so that new attributes end up in the
+// right place.
+func (c *fluentCodec) encode(cat *catalog) ([]byte, error) {
+	attributes := make(map[string][]*message)
+	for _, m := range cat.Messages {
+		if i := strings.Index(m.Key, "."); i != -1 {
+			attributes[m.Key[:i]] = append(attributes[m.Key[:i]], m)
+		}
+	}
+	var buf bytes.Buffer
+	written := make(map[string]bool)
+	for _, m := range cat.Messages {
+		id := m.Key
+		if i := strings.Index(id, "."); i != -1 {
+			id = id[:i]
+			if written[id] || cat.find(id) != nil {
+				continue
+			}
+			// attributes of a message that is not in the catalog
+			m = &message{Key: id, Flags: []string{untranslatableFlag}}
+		}
+		// comments are separated from the messages before them
+		if m.Comment != "" && buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n\n")) {
+			buf.WriteByte('\n')
+		}
+		if m.Key == "" {
+			buf.WriteString(m.Comment + "\n\n")
+			continue
+		}
+		if m.Comment != "" {
+			for _, line := range strings.Split(m.Comment, "\n") {
+				buf.WriteString(strings.TrimRight("# "+line, " ") + "\n")
+			}
+		}
+		buf.WriteString(id + " =")
+		writeFluentValue(&buf, m, 1)
+		for _, attribute := range attributes[id] {
+			buf.WriteString("    ." + attribute.Key[len(id)+1:] + " =")
+			writeFluentValue(&buf, attribute, 2)
+		}
+		written[id] = true
+	}
+	return buf.Bytes(), nil
+}
+
+// writeFluentValue writes the value of m after the = of its entry, with
+// the lines after the first indented to depth.
+func writeFluentValue(buf *bytes.Buffer, m *message, depth int) {
+	indent := strings.Repeat("    ", depth)
+	if m.Plurals != nil {
+		variable := m.KeyPlural
+		if variable == "" {
+			variable = "count"
+		}
+		buf.WriteString("\n" + indent + "{ $" + variable + " ->\n")
+		categories := pluralKeys(m.Plurals)
+		for _, category := range categories {
+			// the other form is the default variant, or the last form if
+			// there is none
+			prefix := indent + "    ["
+			if _, ok := m.Plurals["other"]; category == "other" || (!ok && category == categories[len(categories)-1]) {
+				prefix = indent + "   *["
+			}
+			value := strings.Replace(m.Plurals[category], "\n", "\n"+indent+"        ", -1)
+			buf.WriteString(prefix + category + "] " + value + "\n")
+		}
+		buf.WriteString(indent + "}\n")
+		return
+	}
+	if m.Value == "" && m.hasFlag(untranslatableFlag) {
+		buf.WriteByte('\n')
+		return
+	}
+	if !strings.Contains(m.Value, "\n") {
+		buf.WriteString(" " + m.Value + "\n")
+		return
+	}
+	buf.WriteByte('\n')
+	for _, line := range strings.Split(m.Value, "\n") {
+		if line == "" {
+			buf.WriteByte('\n')
+		} else {
+			buf.WriteString(indent + line + "\n")
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"testing"
+)
+
+func TestFluentCodec_roundTrip(t *testing.T) {
+	content := `### Messages of the login page
+
+-brand-name = Firefox
+
+# Shown on the first page
+# $name (String) - The name of the user
+welcome = Welcome to { -brand-name }, { $name }!
+login-input = Predefined value
+    .placeholder = email@example.com
+    .aria-label = Login input value
+emails =
+    { $unreadEmails ->
+        [one] You have one unread email.
+       *[other] You have { $unreadEmails } unread emails.
+    }
+about =
+    First line
+    Second line
+
+## Footer
+
+footer =
+    .title = Footer
+`
+	cat := testRoundTrip(t, &fluentCodec{}, content)
+	if len(cat.Messages) != 11 {
+		t.Fatalf("decode expected 11 messages, got %d", len(cat.Messages))
+	}
+	if m := cat.Messages[2]; m.Key != "welcome" || m.Comment != "Shown on the first page\n$name (String) - The name of the user" {
+		t.Errorf("decode returned unexpected message %+v", m)
+	}
+	if m := cat.Messages[6]; m.KeyPlural != "unreadEmails" || m.Plurals["one"] != "You have one unread email." {
+		t.Errorf("decode returned unexpected plural message %+v", m)
+	}
+	keys := cat.flatten()
+	want := map[string]string{
+		"-brand-name":             "Firefox",
+		"welcome":                 "Welcome to { -brand-name }, { $name }!",
+		"login-input":             "Predefined value",
+		"login-input.placeholder": "email@example.com",
+		"login-input.aria-label":  "Login input value",
+		"emails.one":              "You have one unread email.",
+		"emails.other":            "You have { $unreadEmails } unread emails.",
+		"about":                   "First line\nSecond line",
+		"footer.title":            "Footer",
+	}
+	for k, v := range want {
+		if keys[k] != v {
+			t.Errorf("flatten expected %q for %s, got %q", v, k, keys[k])
+		}
+	}
+	if len(keys) != len(want) {
+		t.Errorf("flatten expected %d keys, got %v", len(want), keys)
+	}
+}
+
+func TestFluentCodec_selectExpression(t *testing.T) {
+	cat, err := (&fluentCodec{}).decode([]byte(`shared = { $gender ->
+    [male] He shared a photo
+   *[other] They shared a photo
+}
+`))
+	if err != nil {
+		t.Fatalf("decode returned error %v", err)
+	}
+	if m := cat.Messages[0]; m.Plurals != nil || m.Value == "" {
+		t.Errorf("select expressions on other variants should be kept as they are, got %+v", m)
+	}
+}
+
+func TestFluentCodec_encodeAttributes(t *testing.T) {
+	cat := &catalog{Messages: []*message{
+		{Key: "title", Value: "Title"},
+		{Key: "photos", Plurals: map[string]string{"one": "One photo", "other": "{ $count } photos"}},
+		{Key: "title.tooltip", Value: "Tooltip"},
+		{Key: "button.label", Value: "OK"},
+	}}
+	b, err := (&fluentCodec{}).encode(cat)
+	if err != nil {
+		t.Fatalf("encode returned error %v", err)
+	}
+	want := `title = Title
+    .tooltip = Tooltip
+photos =
+    { $count ->
+        [one] One photo
+       *[other] { $count } photos
+    }
+button =
+    .label = OK
+`
+	if string(b) != want {
+		t.Errorf("encode expected\n%s\ngot\n%s", want, b)
+	}
+}
+
+func TestFluentCodec_invalid(t *testing.T) {
+	if _, err := (&fluentCodec{}).decode([]byte("hello world\n")); err == nil {
+		t.Error("decode should return an error for invalid lines")
+	}
+}
@@ -0,0 +1,340 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/weynsee/go-phrase/phrase"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// gettextCodec reads and writes gettext .po and .pot files. The header
+// is kept as a message with an empty key. Plural forms are keyed by the
+// index of their msgstr. Obsolete #~ entries are kept as they are, and
+// written after the other entries.
+type gettextCodec struct{}
+
+func (c *gettextCodec) decode(b []byte) (*catalog, error) {
+	cat := new(catalog)
+	lines := strings.Split(strings.Replace(string(b), "\r\n", "\n", -1), "\n")
+	var m *message
+	var comments, descriptions []string
+	// target points to the string that continuation lines are appended to,
+	// which follows keyword
+	var target *string
+	var keyword string
+	var plural string
+	hasMsgid := false
+	// raw holds the comment lines of the current entry, which are kept with
+	// it if it turns out to be obsolete
+	var raw []string
+	obsolete := false
+
+	flush := func() {
+		if m != nil && hasMsgid && !obsolete {
+			m.Comment = strings.Join(comments, "\n")
+			m.Description = strings.Join(descriptions, "\n")
+			cat.Messages = append(cat.Messages, m)
+		}
+		m, target, hasMsgid = nil, nil, false
+		comments, descriptions, raw, obsolete = nil, nil, nil, false
+	}
+	current := func() *message {
+		if m == nil {
+			m = new(message)
+		}
+		return m
+	}
+
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			flush()
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			if hasMsgid || (obsolete && !strings.HasPrefix(line, "#~")) {
+				// comments start a new entry
+				flush()
+			}
+			target = nil
+			if strings.HasPrefix(line, "#~") {
+				if !obsolete && len(cat.Trailer) > 0 {
+					cat.Trailer = append(cat.Trailer, "")
+				}
+				if !obsolete {
+					cat.Trailer = append(cat.Trailer, raw...)
+				}
+				cat.Trailer = append(cat.Trailer, line)
+				obsolete = true
+				continue
+			}
+			raw = append(raw, line)
+			switch {
+			case strings.HasPrefix(line, "#."):
+				descriptions = append(descriptions, strings.TrimSpace(line[2:]))
+			case strings.HasPrefix(line, "#:"):
+				current().References = append(current().References, strings.Fields(line[2:])...)
+			case strings.HasPrefix(line, "#,"):
+				for _, flag := range strings.Split(line[2:], ",") {
+					if flag = strings.TrimSpace(flag); flag != "" {
+						current().Flags = append(current().Flags, flag)
+					}
+				}
+			case strings.HasPrefix(line, "#|"):
+				// previous strings are dropped
+				current()
+			default:
+				comments = append(comments, strings.TrimSpace(line[1:]))
+			}
+			continue
+		}
+		if line[0] == '"' {
+			if target == nil {
+				return nil, fmt.Errorf("line %d: unexpected string", i+1)
+			}
+			s, err := unquotePO(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", i+1, err.Error())
+			}
+			e := current()
+			if e.Lines == nil {
+				e.Lines = make(map[string][]string)
+			}
+			if e.Lines[keyword] == nil {
+				e.Lines[keyword] = []string{*target}
+			}
+			e.Lines[keyword] = append(e.Lines[keyword], s)
+			*target += s
+			if plural != "" {
+				current().Plurals[plural] = *target
+			}
+			continue
+		}
+
+		rest := ""
+		keyword = line
+		if sep := strings.IndexAny(line, " \t"); sep != -1 {
+			keyword, rest = line[:sep], strings.TrimSpace(line[sep:])
+		}
+		s, err := unquotePO(rest)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", i+1, err.Error())
+		}
+		if (keyword == "msgctxt" || keyword == "msgid") && hasMsgid {
+			// entries are not always separated by blank lines
+			flush()
+		}
+		e := current()
+		plural = ""
+		switch {
+		case keyword == "msgctxt":
+			e.Context = s
+			target = &e.Context
+		case keyword == "msgid":
+			e.Key = s
+			target = &e.Key
+			hasMsgid = true
+		case keyword == "msgid_plural":
+			e.KeyPlural = s
+			target = &e.KeyPlural
+		case keyword == "msgstr":
+			e.Value = s
+			target = &e.Value
+		case strings.HasPrefix(keyword, "msgstr[") && strings.HasSuffix(keyword, "]"):
+			plural = keyword[len("msgstr[") : len(keyword)-1]
+			if _, err := strconv.Atoi(plural); err != nil {
+				return nil, fmt.Errorf("line %d: invalid plural form %s", i+1, keyword)
+			}
+			if e.Plurals == nil {
+				e.Plurals = make(map[string]string)
+			}
+			e.Plurals[plural] = s
+			value := s
+			target = &value
+		default:
+			return nil, fmt.Errorf("line %d: unknown keyword %s", i+1, keyword)
+		}
+	}
+	flush()
+	return cat, nil
+}
+
+// header returns the value of a field in the header of a gettext catalog,
+// e.g. Language or Plural-Forms.
+func (c *gettextCodec) header(cat *catalog, name string) string {
+	for _, m := range cat.Messages {
+		if m.Key != "" || m.Context != "" {
+			continue
+		}
+		for _, line := range strings.Split(m.Value, "\n") {
+			if i := strings.IndexByte(line, ':'); i != -1 && strings.TrimSpace(line[:i]) == name {
+				return strings.TrimSpace(line[i+1:])
+			}
+		}
+	}
+	return ""
+}
+
+var nplurals = regexp.MustCompile(`nplurals\s*=\s*(\d+)`)
+
+// pluralForms returns the number of plural forms declared by the
+// Plural-Forms header, or 0 when there is none.
+func (c *gettextCodec) pluralForms(cat *catalog) (int, error) {
+	header := c.header(cat, "Plural-Forms")
+	if header == "" {
+		return 0, nil
+	}
+	match := nplurals.FindStringSubmatch(header)
+	if match == nil {
+		return 0, fmt.Errorf("Invalid Plural-Forms header: %s", header)
+	}
+	return strconv.Atoi(match[1])
+}
+
+// validate checks that the Plural-Forms header and the plural messages
+// have as many plural forms as the pluralizations of the locale.
+func (c *gettextCodec) validate(cat *catalog, locale *phrase.Locale) []error {
+	var errs []error
+	expected, err := c.pluralForms(cat)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if n := len(locale.Pluralizations); n > 0 {
+		if expected > 0 && expected != n {
+			errs = append(errs, fmt.Errorf("Plural-Forms header declares %d plural forms, but locale %s has %d", expected, locale.Name, n))
+		}
+		expected = n
+	}
+	if expected == 0 {
+		return errs
+	}
+	for _, m := range cat.Messages {
+		if m.Plurals != nil && len(m.Plurals) != expected {
+			errs = append(errs, fmt.Errorf("Key %s has %d plural forms, expected %d", m.Key, len(m.Plurals), expected))
+		}
+	}
+	return errs
+}
+
+func unquotePO(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("invalid string %s", s)
+	}
+	var buf bytes.Buffer
+	for i := 1; i < len(s)-1; i++ {
+		if s[i] != '\\' {
+			buf.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i == len(s)-1 {
+			return "", fmt.Errorf("invalid string %s", s)
+		}
+		switch s[i] {
+		case 'n':
+			buf.WriteByte('\n')
+		case 't':
+			buf.WriteByte('\t')
+		case 'r':
+			buf.WriteByte('\r')
+		case 'a':
+			buf.WriteByte('\a')
+		case 'b':
+			buf.WriteByte('\b')
+		case 'f':
+			buf.WriteByte('\f')
+		case 'v':
+			buf.WriteByte('\v')
+		default:
+			buf.WriteByte(s[i])
+		}
+	}
+	return buf.String(), nil
+}
+
+func (c *gettextCodec) encode(cat *catalog) ([]byte, error) {
+	var buf bytes.Buffer
+	for i, m := range cat.Messages {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		writePOComments(&buf, "# ", m.Comment)
+		writePOComments(&buf, "#. ", m.Description)
+		if len(m.References) > 0 {
+			buf.WriteString("#: " + strings.Join(m.References, " ") + "\n")
+		}
+		if len(m.Flags) > 0 {
+			buf.WriteString("#, " + strings.Join(m.Flags, ", ") + "\n")
+		}
+		if m.Context != "" {
+			writePOString(&buf, m, "msgctxt", m.Context)
+		}
+		writePOString(&buf, m, "msgid", m.Key)
+		if m.KeyPlural != "" || m.Plurals != nil {
+			writePOString(&buf, m, "msgid_plural", m.KeyPlural)
+			forms := make([]int, 0, len(m.Plurals))
+			for form := range m.Plurals {
+				n, err := strconv.Atoi(form)
+				if err != nil {
+					return nil, fmt.Errorf("Invalid plural form %s of key %s", form, m.Key)
+				}
+				forms = append(forms, n)
+			}
+			sort.Ints(forms)
+			for _, n := range forms {
+				writePOString(&buf, m, fmt.Sprintf("msgstr[%d]", n), m.Plurals[strconv.Itoa(n)])
+			}
+		} else {
+			writePOString(&buf, m, "msgstr", m.Value)
+		}
+	}
+	for i, line := range cat.Trailer {
+		if i == 0 && len(cat.Messages) > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(line + "\n")
+	}
+	return buf.Bytes(), nil
+}
+
+func writePOComments(buf *bytes.Buffer, prefix, comment string) {
+	if comment == "" {
+		return
+	}
+	for _, line := range strings.Split(comment, "\n") {
+		buf.WriteString(strings.TrimRight(prefix+line, " ") + "\n")
+	}
+}
+
+// writePOString writes a keyword of m and its string. Strings are split
+// over the lines they were read from, or else into one line per line break
+// after an empty first line.
+func writePOString(buf *bytes.Buffer, m *message, keyword, s string) {
+	if lines := m.Lines[keyword]; strings.Join(lines, "") == s && len(lines) > 1 {
+		buf.WriteString(keyword + " " + quotePO(lines[0]) + "\n")
+		for _, line := range lines[1:] {
+			buf.WriteString(quotePO(line) + "\n")
+		}
+		return
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if len(lines) > 1 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 1 {
+		buf.WriteString(keyword + " " + quotePO(s) + "\n")
+		return
+	}
+	buf.WriteString(keyword + " \"\"\n")
+	for _, line := range lines {
+		buf.WriteString(quotePO(line) + "\n")
+	}
+}
+
+func quotePO(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+	return `"` + r.Replace(s) + `"`
+}
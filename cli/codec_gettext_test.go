@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"github.com/weynsee/go-phrase/phrase"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGettextCodec_roundTrip(t *testing.T) {
+	content := `msgid ""
+msgstr ""
+"Language: de\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+# translator comment
+#. extracted comment
+#: main.c:10 main.c:12
+#, fuzzy, c-format
+msgctxt "menu"
+msgid "Open"
+msgstr "Öffnen"
+
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] "%d Datei"
+msgstr[1] "%d Dateien"
+`
+	cat := testRoundTrip(t, &gettextCodec{}, content)
+	if len(cat.Messages) != 3 {
+		t.Fatalf("decode expected 3 messages, got %d", len(cat.Messages))
+	}
+	if m := cat.Messages[0]; m.Key != "" || m.Value != "Language: de\nPlural-Forms: nplurals=2; plural=(n != 1);\n" {
+		t.Errorf("decode should read the header, got %+v", m)
+	}
+	m := cat.Messages[1]
+	if m.Context != "menu" || m.Value != "Öffnen" || m.Comment != "translator comment" || m.Description != "extracted comment" {
+		t.Errorf("decode returned unexpected message %+v", m)
+	}
+	if !reflect.DeepEqual(m.References, []string{"main.c:10", "main.c:12"}) || !reflect.DeepEqual(m.Flags, []string{"fuzzy", "c-format"}) {
+		t.Errorf("decode should read references and flags, got %+v", m)
+	}
+	if m := cat.Messages[2]; m.KeyPlural != "%d files" || m.Plurals["1"] != "%d Dateien" {
+		t.Errorf("decode should read plurals, got %+v", m)
+	}
+}
+
+func TestGettextCodec_roundTripLines(t *testing.T) {
+	content := `msgid ""
+msgstr ""
+"Language: de\n"
+
+msgid ""
+"A long message that is "
+"wrapped"
+msgstr "Eine lange Nachricht, die umbrochen ist"
+`
+	cat := testRoundTrip(t, &gettextCodec{}, content)
+	if m := cat.Messages[1]; m.Key != "A long message that is wrapped" {
+		t.Errorf("decode should join the lines of strings, got %+v", m)
+	}
+
+	cat.Messages[1].Key = "A short message"
+	cat.Messages[1].Value = "Eine Nachricht\nüber zwei Zeilen"
+	b, err := (&gettextCodec{}).encode(cat)
+	if err != nil {
+		t.Fatalf("encode returned error %v", err)
+	}
+	want := "msgid \"\"\nmsgstr \"\"\n\"Language: de\\n\"\n\nmsgid \"A short message\"\nmsgstr \"\"\n\"Eine Nachricht\\n\"\n\"über zwei Zeilen\"\n"
+	if string(b) != want {
+		t.Errorf("encode should split changed strings on line breaks, expected %q, got %q", want, string(b))
+	}
+}
+
+func TestGettextCodec_obsolete(t *testing.T) {
+	content := `msgid "Open"
+msgstr "Öffnen"
+
+# removed in 2.0
+#~ msgid "Close"
+#~ msgstr "Schließen"
+
+#~ msgid "Quit"
+#~ msgstr "Beenden"
+`
+	cat := testRoundTrip(t, &gettextCodec{}, content)
+	if len(cat.Messages) != 1 {
+		t.Fatalf("decode should not read obsolete entries as messages, got %d", len(cat.Messages))
+	}
+
+	merged, err := mergeFiles(&gettextCodec{}, []byte(content), []byte("msgid \"Open\"\nmsgstr \"Auf\"\n"), encodingUTF8)
+	if err != nil {
+		t.Fatalf("mergeFiles returned error %v", err)
+	}
+	if want := strings.Replace(content, "Öffnen", "Auf", 1); string(merged) != want {
+		t.Errorf("mergeFiles should keep obsolete entries, expected %s, got %s", want, merged)
+	}
+}
+
+func TestGettextCodec_error(t *testing.T) {
+	for _, content := range []string{`msgid "a`, "msgid \"a\"\nmsgfoo \"b\"", `"a"`, "msgid \"a\"\nmsgstr[x] \"b\""} {
+		if _, err := (&gettextCodec{}).decode([]byte(content)); err == nil {
+			t.Errorf("decode should return an error for %q", content)
+		}
+	}
+}
+
+func TestGettextCodec_validate(t *testing.T) {
+	content := `msgid ""
+msgstr "Plural-Forms: nplurals=3; plural=(n==1 ? 0 : n==2 ? 1 : 2);\n"
+
+msgid "file"
+msgid_plural "files"
+msgstr[0] "a"
+msgstr[1] "b"
+`
+	c := &gettextCodec{}
+	cat, err := c.decode([]byte(content))
+	if err != nil {
+		t.Fatalf("decode returned error %v", err)
+	}
+	if n, _ := c.pluralForms(cat); n != 3 {
+		t.Errorf("pluralForms expected 3, got %d", n)
+	}
+	locale := &phrase.Locale{Name: "de", Pluralizations: map[string]map[string]string{"one": nil, "other": nil}}
+	errs := c.validate(cat, locale)
+	if len(errs) != 1 || errs[0].Error() != "Plural-Forms header declares 3 plural forms, but locale de has 2" {
+		t.Errorf("validate returned unexpected errors %v", errs)
+	}
+	if errs := c.validate(cat, &phrase.Locale{Name: "de"}); len(errs) != 1 || errs[0].Error() != "Key file has 2 plural forms, expected 3" {
+		t.Errorf("validate returned unexpected errors %v", errs)
+	}
+}
@@ -0,0 +1,395 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	syntaxTOML = "toml"
+	syntaxJSON = "json"
+	syntaxYAML = "yaml"
+)
+
+// goI18nCodec reads and writes go-i18n v2 message files in TOML, JSON or
+// YAML. Messages are either a string, or an object with a description,
+// a hash and the plural forms of the translation. Messages that only have
+// the other form are read as messages without plural forms. Objects
+// without any of these fields nest messages, whose ids are joined by dots,
+// and are written back nested. The syntax of a file is detected when it is
+// read, new files are TOML.
+type goI18nCodec struct{}
+
+// goI18nFields are the fields of go-i18n messages that are neither plural
+// forms nor the description, kept as the metadata of the message.
+var goI18nFields = []string{"id", "hash", "leftdelim", "rightdelim", "translation"}
+
+func isGoI18nMessage(v *jsonValue) bool {
+	for _, key := range v.keys {
+		if key == "description" || isPluralCategory(key) || isGoI18nField(key) {
+			return true
+		}
+	}
+	return false
+}
+
+func isGoI18nField(key string) bool {
+	for _, field := range goI18nFields {
+		if key == field {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *goI18nCodec) decode(b []byte) (*catalog, error) {
+	trimmed := bytes.TrimSpace(b)
+	var root *jsonValue
+	var syntax string
+	var err error
+	switch {
+	case len(trimmed) > 0 && trimmed[0] == '{':
+		syntax = syntaxJSON
+		root, err = decodeJSONObject(b)
+	default:
+		syntax = syntaxTOML
+		root, err = decodeTOMLObject(b)
+		if err != nil {
+			// YAML files are rarely valid TOML
+			var yamlErr error
+			if root, yamlErr = decodeYAMLObject(b); yamlErr == nil {
+				syntax, err = syntaxYAML, nil
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	cat := &catalog{Syntax: syntax}
+	if err = c.decodeObject(cat, nil, root); err != nil {
+		return nil, err
+	}
+	return cat, nil
+}
+
+func (c *goI18nCodec) decodeObject(cat *catalog, prefix []string, object *jsonValue) error {
+	for i, key := range object.keys {
+		value := object.values[i]
+		path := append(prefix[:len(prefix):len(prefix)], key)
+		id := strings.Join(path, ".")
+		switch {
+		case value.kind == jsonScalar:
+			cat.Messages = append(cat.Messages, &message{Key: id, Path: path, Value: value.scalar})
+		case value.kind == jsonObject && isGoI18nMessage(value):
+			m := c.decodeMessage(id, value)
+			m.Path = path
+			cat.Messages = append(cat.Messages, m)
+		case value.kind == jsonObject:
+			if err := c.decodeObject(cat, path, value); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("Message %s must be a string or an object", id)
+		}
+	}
+	return nil
+}
+
+func (c *goI18nCodec) decodeMessage(id string, value *jsonValue) *message {
+	m := &message{Key: id}
+	plurals := make(map[string]string)
+	for i, key := range value.keys {
+		field := value.values[i]
+		switch {
+		case key == "description":
+			m.Description = field.scalar
+		case isPluralCategory(key):
+			plurals[key] = field.scalar
+		default:
+			if m.Metadata == nil {
+				m.Metadata = &jsonValue{kind: jsonObject}
+			}
+			m.Metadata.set(key, field)
+		}
+	}
+	if other, ok := plurals["other"]; ok && len(plurals) == 1 {
+		m.Value = other
+	} else if len(plurals) > 0 {
+		m.Plurals = plurals
+	}
+	if m.Metadata != nil && len(plurals) == 0 {
+		// older files keep the translation in its own field
+		if translation := m.Metadata.get("translation"); translation != nil {
+			m.Value = translation.scalar
+			m.Metadata.remove("translation")
+			if len(m.Metadata.keys) == 0 {
+				m.Metadata = nil
+			}
+		}
+	}
+	return m
+}
+
+func decodeTOMLObject(b []byte) (*jsonValue, error) {
+	var data map[string]interface{}
+	md, err := toml.Decode(string(b), &data)
+	if err != nil {
+		return nil, err
+	}
+	root := &jsonValue{kind: jsonObject}
+	// the metadata lists the keys in the order of the file
+	for _, key := range md.Keys() {
+		parent, value := root, interface{}(data)
+		for _, part := range key {
+			table, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("Unsupported value of %s: messages must be strings or tables", strings.Join(key, "."))
+			}
+			value = table[part]
+			child := parent.get(part)
+			if child == nil {
+				if child, ok = tomlValue(value); !ok {
+					return nil, fmt.Errorf("Unsupported value of %s: messages must be strings or tables", strings.Join(key, "."))
+				}
+				parent.set(part, child)
+			}
+			parent = child
+		}
+	}
+	return root, nil
+}
+
+// tomlValue returns the value of a table, a string, a number or a boolean.
+// Other values, like arrays and dates, have no place in message files.
+func tomlValue(v interface{}) (*jsonValue, bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return &jsonValue{kind: jsonObject}, true
+	case string:
+		return &jsonValue{scalar: v}, true
+	case int64, bool:
+		s := fmt.Sprint(v)
+		return &jsonValue{scalar: s, literal: s}, true
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return nil, false
+		}
+		s := strconv.FormatFloat(v, 'f', -1, 64)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		return &jsonValue{scalar: s, literal: s}, true
+	}
+	return nil, false
+}
+
+func decodeYAMLObject(b []byte) (*jsonValue, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return &jsonValue{kind: jsonObject}, nil
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("YAML locale files must contain a mapping")
+	}
+	return yamlValue(doc.Content[0], "")
+}
+
+// yamlValue returns the value of a mapping or a scalar. Sequences have no
+// place in message files.
+func yamlValue(node *yaml.Node, key string) (*jsonValue, error) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	switch node.Kind {
+	case yaml.ScalarNode:
+		v := &jsonValue{scalar: node.Value}
+		if node.ShortTag() != "!!str" {
+			v.literal = node.Value
+		}
+		return v, nil
+	case yaml.MappingNode:
+		v := &jsonValue{kind: jsonObject}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			child, err := yamlValue(node.Content[i+1], joinKey(key, node.Content[i].Value))
+			if err != nil {
+				return nil, err
+			}
+			v.set(node.Content[i].Value, child)
+		}
+		return v, nil
+	}
+	return nil, fmt.Errorf("Unsupported value of %s: messages must be strings or mappings", key)
+}
+
+func (c *goI18nCodec) encode(cat *catalog) ([]byte, error) {
+	root := &jsonValue{kind: jsonObject}
+	// values of messages, which cannot nest other messages
+	messages := make(map[*jsonValue]string)
+	for _, m := range cat.Messages {
+		path := m.Path
+		if path == nil {
+			path = []string{m.Key}
+		}
+		parent := root
+		for _, part := range path[:len(path)-1] {
+			child := parent.get(part)
+			if child == nil {
+				child = &jsonValue{kind: jsonObject}
+				parent.set(part, child)
+			}
+			if key, ok := messages[child]; ok {
+				return nil, fmt.Errorf("Key %s conflicts with key %s", m.Key, key)
+			}
+			parent = child
+		}
+		last := path[len(path)-1]
+		if parent.get(last) != nil {
+			return nil, fmt.Errorf("Key %s is defined more than once", m.Key)
+		}
+		value := c.encodeMessage(m)
+		messages[value] = m.Key
+		parent.set(last, value)
+	}
+	var buf bytes.Buffer
+	switch cat.Syntax {
+	case syntaxJSON:
+		writeJSONValue(&buf, root, 1)
+		buf.WriteByte('\n')
+	case syntaxYAML:
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		for i, key := range root.keys {
+			node.Content = append(node.Content, yamlString(key), yamlNode(root.values[i]))
+		}
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(node); err != nil {
+			return nil, err
+		}
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+	default:
+		writeTOML(&buf, nil, root)
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeMessage returns a string for messages that are just a translation,
+// and an object for all others.
+func (c *goI18nCodec) encodeMessage(m *message) *jsonValue {
+	if m.Description == "" && m.Metadata == nil && m.Plurals == nil {
+		return &jsonValue{scalar: m.Value}
+	}
+	v := &jsonValue{kind: jsonObject}
+	if m.Description != "" {
+		v.set("description", &jsonValue{scalar: m.Description})
+	}
+	if m.Metadata != nil {
+		for i, key := range m.Metadata.keys {
+			v.set(key, m.Metadata.values[i])
+		}
+	}
+	if m.Plurals == nil {
+		v.set("other", &jsonValue{scalar: m.Value})
+	}
+	for _, category := range pluralKeys(m.Plurals) {
+		v.set(category, &jsonValue{scalar: m.Plurals[category]})
+	}
+	return v
+}
+
+func yamlNode(v *jsonValue) *yaml.Node {
+	if v.kind != jsonObject {
+		if v.literal != "" {
+			return &yaml.Node{Kind: yaml.ScalarNode, Value: v.literal}
+		}
+		return yamlString(v.scalar)
+	}
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for i, key := range v.keys {
+		node.Content = append(node.Content, yamlString(key), yamlNode(v.values[i]))
+	}
+	return node
+}
+
+// writeTOML writes the values of a table that are not tables first, since
+// TOML does not allow keys after tables, followed by its tables, headed by
+// their path. Tables that only hold tables get no header of their own.
+func writeTOML(buf *bytes.Buffer, path []string, table *jsonValue) {
+	header := len(path) == 0
+	for i, key := range table.keys {
+		if table.values[i].kind == jsonObject {
+			continue
+		}
+		if !header {
+			if buf.Len() > 0 {
+				buf.WriteByte('\n')
+			}
+			keys := make([]string, len(path))
+			for j, part := range path {
+				keys[j] = tomlKey(part)
+			}
+			buf.WriteString("[" + strings.Join(keys, ".") + "]\n")
+			header = true
+		}
+		buf.WriteString(tomlKey(key) + " = " + tomlScalar(table.values[i]) + "\n")
+	}
+	for i, key := range table.keys {
+		if table.values[i].kind == jsonObject {
+			writeTOML(buf, append(path[:len(path):len(path)], key), table.values[i])
+		}
+	}
+}
+
+var tomlBareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func tomlKey(key string) string {
+	if tomlBareKey.MatchString(key) {
+		return key
+	}
+	return quoteTOML(key)
+}
+
+func tomlScalar(v *jsonValue) string {
+	if v.literal != "" {
+		return v.literal
+	}
+	return quoteTOML(v.scalar)
+}
+
+func quoteTOML(s string) string {
+	var buf bytes.Buffer
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\r':
+			buf.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&buf, `\u%04X`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"testing"
+)
+
+func TestGoI18nCodec_roundTripTOML(t *testing.T) {
+	content := `HelloWorld = "Hello World!"
+"nav.home" = "Home"
+
+[PersonCats]
+description = "The number of cats a person has"
+hash = "sha1-8ac1a5f3b4fe3a4ac8fa4ef3a4bd8b6b4d1b1d87"
+one = "{{.Name}} has {{.Count}} cat."
+other = "{{.Name}} has {{.Count}} cats."
+
+[Greeting]
+description = "Says \"hi\""
+other = "Hi,\n{{.Name}}"
+`
+	cat := testRoundTrip(t, &goI18nCodec{}, content)
+	if cat.Syntax != syntaxTOML {
+		t.Errorf("decode expected toml syntax, got %q", cat.Syntax)
+	}
+	if len(cat.Messages) != 4 {
+		t.Fatalf("decode expected 4 messages, got %d", len(cat.Messages))
+	}
+	if m := cat.Messages[2]; m.Key != "PersonCats" || len(m.Plurals) != 2 || m.Description != "The number of cats a person has" {
+		t.Errorf("decode returned unexpected message %+v", m)
+	}
+	if m := cat.Messages[3]; m.Value != "Hi,\n{{.Name}}" || m.Plurals != nil {
+		t.Errorf("messages with only the other form should not be plural, got %+v", m)
+	}
+	keys := cat.flatten()
+	if keys["PersonCats.one"] != "{{.Name}} has {{.Count}} cat." || keys["nav.home"] != "Home" {
+		t.Errorf("flatten returned unexpected keys %v", keys)
+	}
+}
+
+func TestGoI18nCodec_roundTripJSON(t *testing.T) {
+	content := `{
+  "HelloWorld": "Hello World!",
+  "Unread": {
+    "description": "Unread emails",
+    "one": "You have one unread email",
+    "other": "You have {{.Count}} unread emails"
+  }
+}
+`
+	cat := testRoundTrip(t, &goI18nCodec{}, content)
+	if cat.Syntax != syntaxJSON || len(cat.Messages) != 2 {
+		t.Errorf("decode returned unexpected catalog %+v", cat)
+	}
+}
+
+func TestGoI18nCodec_roundTripYAML(t *testing.T) {
+	content := `HelloWorld: Hello World!
+Unread:
+  description: Unread emails
+  one: You have one unread email
+  other: You have {{.Count}} unread emails
+`
+	cat := testRoundTrip(t, &goI18nCodec{}, content)
+	if cat.Syntax != syntaxYAML || len(cat.Messages) != 2 {
+		t.Errorf("decode returned unexpected catalog %+v", cat)
+	}
+}
+
+func TestGoI18nCodec_nested(t *testing.T) {
+	cat, err := (&goI18nCodec{}).decode([]byte(`[nav]
+home = "Home"
+
+[nav.settings]
+other = "Settings"
+`))
+	if err != nil {
+		t.Fatalf("decode returned error %v", err)
+	}
+	keys := cat.flatten()
+	if len(keys) != 2 || keys["nav.home"] != "Home" || keys["nav.settings"] != "Settings" {
+		t.Errorf("decode should join the ids of nested messages, got %v", keys)
+	}
+	testRoundTrip(t, &goI18nCodec{}, `title = "Title"
+
+[nav]
+home = "Home"
+
+[nav.settings]
+description = "Settings of the app"
+other = "Settings"
+
+[nav.settings.menu]
+count = 3
+ratio = 1.0
+visible = true
+`)
+}
+
+func TestGoI18nCodec_unsupported(t *testing.T) {
+	for _, content := range []string{
+		"[[items]]\nid = \"x\"\n",
+		"tags = [\"a\", \"b\"]\n",
+		"released = 2020-01-02T03:04:05Z\n",
+		"items:\n  - a\n  - b\n",
+	} {
+		if _, err := (&goI18nCodec{}).decode([]byte(content)); err == nil {
+			t.Errorf("decode of %q should return an error", content)
+		}
+	}
+}
+
+func TestGoI18nCodec_encode(t *testing.T) {
+	cat := &catalog{Messages: []*message{
+		{Key: "Cats", Plurals: map[string]string{"other": "{{.Count}} cats", "one": "1 cat"}},
+		{Key: "Hello", Value: "Hallo"},
+	}}
+	b, err := (&goI18nCodec{}).encode(cat)
+	if err != nil {
+		t.Fatalf("encode returned error %v", err)
+	}
+	want := `Hello = "Hallo"
+
+[Cats]
+one = "1 cat"
+other = "{{.Count}} cats"
+`
+	if string(b) != want {
+		t.Errorf("encode expected\n%s\ngot\n%s", want, b)
+	}
+}
@@ -0,0 +1,360 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// jsonCodec reads and writes JSON locale files, keeping the order of
+// their keys. Nested objects are flattened into dot separated keys, and
+// objects whose keys are all plural categories are read as plural forms.
+// Flat files, like simple_json, keep dots in their keys as they are. Values
+// that are neither, like empty objects or arrays of numbers, are kept as
+// they are.
+type jsonCodec struct {
+	nested bool
+}
+
+// jsonValue is a JSON value that remembers the order of object keys.
+type jsonValue struct {
+	// keys and values are only set for objects
+	keys   []string
+	values []*jsonValue
+	// items is only set for arrays
+	items []*jsonValue
+	// scalar holds the text of strings, numbers and booleans
+	scalar string
+	// literal holds numbers, booleans and null as they are written in JSON
+	literal string
+	kind    json.Delim
+}
+
+const (
+	jsonObject json.Delim = '{'
+	jsonArray  json.Delim = '['
+	jsonScalar json.Delim = 0
+)
+
+func decodeJSONValue(d *json.Decoder) (*jsonValue, error) {
+	t, err := d.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := t.(type) {
+	case json.Delim:
+		v := &jsonValue{kind: t}
+		for d.More() {
+			if t == jsonObject {
+				key, err := d.Token()
+				if err != nil {
+					return nil, err
+				}
+				child, err := decodeJSONValue(d)
+				if err != nil {
+					return nil, err
+				}
+				v.keys = append(v.keys, key.(string))
+				v.values = append(v.values, child)
+			} else {
+				child, err := decodeJSONValue(d)
+				if err != nil {
+					return nil, err
+				}
+				v.items = append(v.items, child)
+			}
+		}
+		// closing delimiter
+		if _, err := d.Token(); err != nil {
+			return nil, err
+		}
+		return v, nil
+	case string:
+		return &jsonValue{scalar: t}, nil
+	case json.Number:
+		return &jsonValue{scalar: t.String(), literal: t.String()}, nil
+	case bool:
+		return &jsonValue{scalar: fmt.Sprint(t), literal: fmt.Sprint(t)}, nil
+	default:
+		return &jsonValue{literal: "null"}, nil
+	}
+}
+
+// tag returns the YAML tag of the type of a scalar that is not a string.
+func (v *jsonValue) tag() string {
+	switch {
+	case v.literal == "":
+		return ""
+	case v.literal == "null":
+		return "!!null"
+	case v.literal == "true" || v.literal == "false":
+		return "!!bool"
+	case strings.ContainsAny(v.literal, ".eE"):
+		return "!!float"
+	}
+	return "!!int"
+}
+
+var jsonNumber = regexp.MustCompile(`\A-?(?:0|[1-9]\d*)(?:\.\d+)?(?:[eE][-+]?\d+)?\z`)
+
+// writeJSONScalar writes the value of m with its type, if it still fits
+// it, or as a string.
+func writeJSONScalar(buf *bytes.Buffer, m *message) {
+	switch {
+	case m.Tag == "!!null" && m.Value == "":
+		buf.WriteString("null")
+	case m.Tag == "!!bool" && (m.Value == "true" || m.Value == "false"),
+		(m.Tag == "!!int" || m.Tag == "!!float") && jsonNumber.MatchString(m.Value):
+		buf.WriteString(m.Value)
+	default:
+		writeJSONString(buf, m.Value)
+	}
+}
+
+func (v *jsonValue) isPlural() bool {
+	if v.kind != jsonObject || len(v.keys) == 0 {
+		return false
+	}
+	for i, key := range v.keys {
+		if !isPluralCategory(key) || v.values[i].kind != jsonScalar {
+			return false
+		}
+	}
+	return true
+}
+
+// isStringArray returns whether v is an array of strings.
+func (v *jsonValue) isStringArray() bool {
+	if v.kind != jsonArray {
+		return false
+	}
+	for _, item := range v.items {
+		if item.kind != jsonScalar || item.literal != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *jsonCodec) decode(b []byte) (*catalog, error) {
+	cat := new(catalog)
+	root, err := decodeJSONObject(b)
+	if err != nil {
+		return nil, err
+	}
+	c.decodeObject(cat, nil, root)
+	return cat, nil
+}
+
+// decodeJSONObject decodes a locale file that contains a single object.
+// Empty files are read as an empty object.
+func decodeJSONObject(b []byte) (*jsonValue, error) {
+	if len(bytes.TrimSpace(b)) == 0 {
+		return &jsonValue{kind: jsonObject}, nil
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	root, err := decodeJSONValue(d)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := d.Token(); err != io.EOF {
+		return nil, errors.New("JSON locale files must contain a single object")
+	}
+	if root.kind != jsonObject {
+		return nil, errors.New("JSON locale files must contain an object")
+	}
+	return root, nil
+}
+
+// get returns the value of key in an object, or nil.
+func (v *jsonValue) get(key string) *jsonValue {
+	for i, k := range v.keys {
+		if k == key {
+			return v.values[i]
+		}
+	}
+	return nil
+}
+
+// set sets the value of key in an object, appending it if it is new.
+func (v *jsonValue) set(key string, value *jsonValue) {
+	for i, k := range v.keys {
+		if k == key {
+			v.values[i] = value
+			return
+		}
+	}
+	v.keys = append(v.keys, key)
+	v.values = append(v.values, value)
+}
+
+// remove removes key from an object.
+func (v *jsonValue) remove(key string) {
+	for i, k := range v.keys {
+		if k == key {
+			v.keys = append(v.keys[:i:i], v.keys[i+1:]...)
+			v.values = append(v.values[:i:i], v.values[i+1:]...)
+			return
+		}
+	}
+}
+
+func (c *jsonCodec) decodeObject(cat *catalog, prefix []string, object *jsonValue) {
+	for i, key := range object.keys {
+		value := object.values[i]
+		m := &message{Key: key}
+		if c.nested {
+			path := append(prefix[:len(prefix):len(prefix)], key)
+			if value.kind == jsonObject && len(value.keys) > 0 && !value.isPlural() {
+				c.decodeObject(cat, path, value)
+				continue
+			}
+			m.Key, m.Path = strings.Join(path, "."), path
+		}
+		switch {
+		case value.isPlural():
+			m.Plurals = make(map[string]string)
+			for j, category := range value.keys {
+				m.Plurals[category] = value.values[j].scalar
+			}
+		case value.isStringArray():
+			m.Array = make([]string, len(value.items))
+			for j, item := range value.items {
+				m.Array[j] = item.scalar
+			}
+		case value.kind == jsonScalar:
+			m.Value, m.Tag = value.scalar, value.tag()
+		default:
+			m.Literal = value
+		}
+		cat.Messages = append(cat.Messages, m)
+	}
+}
+
+func (c *jsonCodec) encode(cat *catalog) ([]byte, error) {
+	var buf bytes.Buffer
+	if c.nested {
+		tree, err := nestKeys(cat.Messages)
+		if err != nil {
+			return nil, err
+		}
+		writeJSONNode(&buf, tree, 1)
+	} else {
+		tree := new(keyNode)
+		for _, m := range cat.Messages {
+			tree.children = append(tree.children, &keyNode{name: m.Key, message: m})
+		}
+		writeJSONNode(&buf, tree, 1)
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+func writeJSONNode(buf *bytes.Buffer, node *keyNode, depth int) {
+	if len(node.children) == 0 {
+		buf.WriteString("{}")
+		return
+	}
+	indent := strings.Repeat("  ", depth)
+	buf.WriteString("{\n")
+	for i, child := range node.children {
+		buf.WriteString(indent)
+		writeJSONString(buf, child.name)
+		buf.WriteString(": ")
+		if m := child.message; m != nil {
+			switch {
+			case m.Plurals != nil:
+				plurals := new(keyNode)
+				for _, category := range pluralKeys(m.Plurals) {
+					plurals.children = append(plurals.children, &keyNode{name: category, message: &message{Value: m.Plurals[category]}})
+				}
+				writeJSONNode(buf, plurals, depth+1)
+			case m.Array != nil:
+				writeJSONArray(buf, m.Array, depth+1)
+			case m.Literal != nil:
+				writeJSONValue(buf, m.Literal, depth+1)
+			default:
+				writeJSONScalar(buf, m)
+			}
+		} else {
+			writeJSONNode(buf, child, depth+1)
+		}
+		if i < len(node.children)-1 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('\n')
+	}
+	buf.WriteString(strings.Repeat("  ", depth-1))
+	buf.WriteByte('}')
+}
+
+func writeJSONArray(buf *bytes.Buffer, items []string, depth int) {
+	if len(items) == 0 {
+		buf.WriteString("[]")
+		return
+	}
+	indent := strings.Repeat("  ", depth)
+	buf.WriteString("[\n")
+	for i, item := range items {
+		buf.WriteString(indent)
+		writeJSONString(buf, item)
+		if i < len(items)-1 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('\n')
+	}
+	buf.WriteString(strings.Repeat("  ", depth-1))
+	buf.WriteByte(']')
+}
+
+// writeJSONValue writes v the way it was read.
+func writeJSONValue(buf *bytes.Buffer, v *jsonValue, depth int) {
+	switch v.kind {
+	case jsonObject, jsonArray:
+		open, end := "{", "}"
+		n := len(v.keys)
+		if v.kind == jsonArray {
+			open, end, n = "[", "]", len(v.items)
+		}
+		if n == 0 {
+			buf.WriteString(open + end)
+			return
+		}
+		indent := strings.Repeat("  ", depth)
+		buf.WriteString(open + "\n")
+		for i := 0; i < n; i++ {
+			buf.WriteString(indent)
+			if v.kind == jsonObject {
+				writeJSONString(buf, v.keys[i])
+				buf.WriteString(": ")
+				writeJSONValue(buf, v.values[i], depth+1)
+			} else {
+				writeJSONValue(buf, v.items[i], depth+1)
+			}
+			if i < n-1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(strings.Repeat("  ", depth-1) + end)
+	default:
+		if v.literal != "" {
+			buf.WriteString(v.literal)
+		} else {
+			writeJSONString(buf, v.scalar)
+		}
+	}
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	// Encode terminates the value with a newline
+	buf.Truncate(buf.Len() - 1)
+}
@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJsonCodec_simple(t *testing.T) {
+	content := `{
+  "app.title": "Title",
+  "hello": "<b>Hello</b> \"you\""
+}
+`
+	cat := testRoundTrip(t, &jsonCodec{}, content)
+	if m := cat.Messages[0]; m.Key != "app.title" || m.Value != "Title" {
+		t.Errorf("decode returned unexpected message %+v", m)
+	}
+}
+
+func TestJsonCodec_nested(t *testing.T) {
+	content := `{
+  "app": {
+    "title": "Title",
+    "items": {
+      "one": "1 item",
+      "other": "{{count}} items"
+    }
+  },
+  "days": [
+    "Mon",
+    "Tue"
+  ]
+}
+`
+	cat := testRoundTrip(t, &jsonCodec{nested: true}, content)
+	if len(cat.Messages) != 3 {
+		t.Fatalf("decode expected 3 messages, got %d", len(cat.Messages))
+	}
+	if m := cat.Messages[1]; m.Key != "app.items" || m.Plurals["one"] != "1 item" {
+		t.Errorf("decode should read plurals, got %+v", m)
+	}
+	if m := cat.Messages[2]; m.Key != "days" || len(m.Array) != 2 {
+		t.Errorf("decode should read arrays, got %+v", m)
+	}
+}
+
+func TestJsonCodec_literals(t *testing.T) {
+	content := `{
+  "app": {
+    "empty": {},
+    "sizes": [
+      1,
+      2.5
+    ],
+    "links": [
+      {
+        "label": "Home",
+        "url": "/"
+      }
+    ]
+  },
+  "none": []
+}
+`
+	for _, nested := range []bool{true, false} {
+		cat := testRoundTrip(t, &jsonCodec{nested: nested}, content)
+		keys := cat.flatten()
+		if nested && (len(cat.Messages) != 4 || cat.Messages[0].Key != "app.empty" || cat.Messages[0].Literal == nil) {
+			t.Errorf("decode should keep empty objects, got %+v", cat.Messages)
+		}
+		if want := "2.5"; nested && keys["app.sizes.1"] != want {
+			t.Errorf("flatten expected %q for the items of arrays of numbers, got %v", want, keys)
+		}
+		if want := "Home"; keys["app.links.0.label"] != want {
+			t.Errorf("flatten expected %q for the objects of arrays, got %v", want, keys)
+		}
+	}
+}
+
+func TestJsonCodec_error(t *testing.T) {
+	for _, content := range []string{`{`, `["a"]`} {
+		if _, err := (&jsonCodec{}).decode([]byte(content)); err == nil {
+			t.Errorf("decode should return an error for %s", content)
+		}
+	}
+}
+
+func TestJsonCodec_types(t *testing.T) {
+	content := `{
+  "count": 1,
+  "ratio": 0.5,
+  "ok": true,
+  "none": null,
+  "text": "1"
+}
+`
+	cat := testRoundTrip(t, &jsonCodec{nested: true}, content)
+	if m := cat.Messages[0]; m.Value != "1" || m.Tag != "!!int" {
+		t.Errorf("decode should keep the type of numbers, got %+v", m)
+	}
+	if m := cat.Messages[4]; m.Tag != "" {
+		t.Errorf("decode should not set a type for strings, got %+v", m)
+	}
+
+	cat.Messages[0].Value = "one"
+	b, _ := (&jsonCodec{nested: true}).encode(cat)
+	if !strings.Contains(string(b), `"count": "one"`) {
+		t.Errorf("encode should write values that no longer fit their type as strings, got %s", b)
+	}
+}
+
+func TestJsonCodec_dottedKeys(t *testing.T) {
+	content := `{
+  "a.b": "dotted",
+  "a": {
+    "c": "nested"
+  }
+}
+`
+	cat := testRoundTrip(t, &jsonCodec{nested: true}, content)
+	if m := cat.Messages[0]; m.Key != "a.b" || len(m.Path) != 1 {
+		t.Errorf("decode should keep the segments of dotted keys, got %+v", m)
+	}
+}
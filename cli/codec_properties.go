@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// propertiesCodec reads and writes Java .properties files. Comments right
+// above a key are kept as its comment.
+type propertiesCodec struct{}
+
+func (c *propertiesCodec) decode(b []byte) (*catalog, error) {
+	cat := new(catalog)
+	var comments []string
+	lines := strings.Split(strings.Replace(string(b), "\r\n", "\n", -1), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimLeft(lines[i], " \t\f")
+		if line == "" {
+			comments = nil
+			continue
+		}
+		if line[0] == '#' || line[0] == '!' {
+			comments = append(comments, strings.TrimSpace(line[1:]))
+			continue
+		}
+		// join continuation lines, which end in an odd number of backslashes
+		for endsWithContinuation(line) && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + strings.TrimLeft(lines[i], " \t\f")
+		}
+		key, value, err := splitProperty(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", i+1, err.Error())
+		}
+		cat.Messages = append(cat.Messages, &message{Key: key, Value: value, Comment: strings.Join(comments, "\n")})
+		comments = nil
+	}
+	return cat, nil
+}
+
+func endsWithContinuation(line string) bool {
+	n := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// splitProperty splits a logical line into its unescaped key and value.
+// The key ends at the first unescaped '=', ':' or whitespace.
+func splitProperty(line string) (string, string, error) {
+	end := len(line)
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.IndexByte("=: \t\f", line[i]) != -1 {
+			end = i
+			break
+		}
+	}
+	key, err := unescapeProperty(line[:end])
+	if err != nil {
+		return "", "", err
+	}
+	rest := strings.TrimLeft(line[end:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+	value, err := unescapeProperty(rest)
+	if err != nil {
+		return "", "", err
+	}
+	return key, value, nil
+}
+
+func unescapeProperty(s string) (string, error) {
+	if strings.IndexByte(s, '\\') == -1 {
+		return s, nil
+	}
+	var buf bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			buf.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			buf.WriteByte('\t')
+		case 'n':
+			buf.WriteByte('\n')
+		case 'r':
+			buf.WriteByte('\r')
+		case 'f':
+			buf.WriteByte('\f')
+		case 'u':
+			r, err := parseUnicodeEscape(s, i+1)
+			if err != nil {
+				return "", err
+			}
+			i += 4
+			// characters outside of the BMP are escaped as surrogate pairs
+			if utf16.IsSurrogate(r) && strings.HasPrefix(s[i+1:], `\u`) {
+				if low, err := parseUnicodeEscape(s, i+3); err == nil {
+					r = utf16.DecodeRune(r, low)
+					i += 6
+				}
+			}
+			buf.WriteRune(r)
+		default:
+			buf.WriteByte(s[i])
+		}
+	}
+	return buf.String(), nil
+}
+
+func parseUnicodeEscape(s string, start int) (rune, error) {
+	if start+4 > len(s) {
+		return 0, fmt.Errorf("invalid unicode escape in %q", s)
+	}
+	r, err := strconv.ParseUint(s[start:start+4], 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid unicode escape in %q", s)
+	}
+	return rune(r), nil
+}
+
+func (c *propertiesCodec) encode(cat *catalog) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, m := range cat.Messages {
+		if m.Comment != "" {
+			for _, line := range strings.Split(m.Comment, "\n") {
+				buf.WriteString("# " + line + "\n")
+			}
+		}
+		buf.WriteString(escapeProperty(m.Key, true))
+		buf.WriteString(" = ")
+		buf.WriteString(escapeProperty(m.Value, false))
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// escapeProperty escapes s for use as a key or a value in a .properties
+// file. Characters outside of ASCII are written as unicode escapes,
+// since .properties files are read as ISO-8859-1 by default.
+func escapeProperty(s string, key bool) string {
+	var buf bytes.Buffer
+	for i, r := range s {
+		switch r {
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '=', ':', '#', '!', ' ':
+			if key || i == 0 {
+				buf.WriteByte('\\')
+			}
+			buf.WriteRune(r)
+		default:
+			if r < 0x20 || r > 0x7e {
+				writeUnicodeEscapes(&buf, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	return buf.String()
+}
+
+func writeUnicodeEscapes(buf *bytes.Buffer, r rune) {
+	if r <= 0xffff {
+		fmt.Fprintf(buf, `\u%04x`, r)
+		return
+	}
+	// characters outside of the BMP are written as surrogate pairs
+	high, low := utf16.EncodeRune(r)
+	fmt.Fprintf(buf, `\u%04x\u%04x`, high, low)
+}
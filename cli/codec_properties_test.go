@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"testing"
+)
+
+func TestPropertiesCodec_roundTrip(t *testing.T) {
+	content := `# greeting
+hello = Hello World
+key\ with\ spaces = caf\u00e9
+emoji = \ud83d\ude00
+`
+	cat := testRoundTrip(t, &propertiesCodec{}, content)
+	if m := cat.Messages[0]; m.Comment != "greeting" || m.Value != "Hello World" {
+		t.Errorf("decode returned unexpected message %+v", m)
+	}
+	if m := cat.Messages[1]; m.Key != "key with spaces" || m.Value != "café" {
+		t.Errorf("decode should unescape keys and values, got %+v", m)
+	}
+	if m := cat.Messages[2]; m.Value != "😀" {
+		t.Errorf("decode should read surrogate pairs, got %+v", m)
+	}
+}
+
+func TestPropertiesCodec_decode(t *testing.T) {
+	content := "! comment\na:b\nc    d\nlong = first \\\n    second\n\nempty\n"
+	cat, err := (&propertiesCodec{}).decode([]byte(content))
+	if err != nil {
+		t.Fatalf("decode returned error %v", err)
+	}
+	want := map[string]string{"a": "b", "c": "d", "long": "first second", "empty": ""}
+	for _, m := range cat.Messages {
+		if want[m.Key] != m.Value {
+			t.Errorf("decode expected %s to be %q, got %q", m.Key, want[m.Key], m.Value)
+		}
+	}
+	if len(cat.Messages) != len(want) {
+		t.Errorf("decode expected %d messages, got %d", len(want), len(cat.Messages))
+	}
+}
+
+func TestPropertiesCodec_error(t *testing.T) {
+	if _, err := (&propertiesCodec{}).decode([]byte(`a = \u12`)); err == nil {
+		t.Error("decode should return an error for invalid unicode escapes")
+	}
+}
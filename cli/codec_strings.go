@@ -0,0 +1,243 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// stringsCodec reads and writes iOS and OS X .strings files. Comments
+// right above a key are kept as its comment. The whitespace and comments
+// around the entries are written back as they were read, unless the
+// comment of the message changed. Files are read as UTF-8 or UTF-16
+// depending on their byte order mark, and written back the same way.
+type stringsCodec struct{}
+
+type stringsParser struct {
+	s    string
+	pos  int
+	line int
+}
+
+func (p *stringsParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *stringsParser) eof() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *stringsParser) advance(n int) {
+	p.line += strings.Count(p.s[p.pos:p.pos+n], "\n")
+	p.pos += n
+}
+
+// skip skips whitespace and returns the comments found along the way.
+func (p *stringsParser) skip() ([]string, error) {
+	var comments []string
+	for !p.eof() {
+		rest := p.s[p.pos:]
+		switch {
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end == -1 {
+				return nil, p.errorf("unterminated comment")
+			}
+			comments = append(comments, strings.TrimSpace(rest[2:end+2]))
+			p.advance(end + 4)
+		case strings.HasPrefix(rest, "//"):
+			end := strings.IndexByte(rest, '\n')
+			if end == -1 {
+				end = len(rest)
+			}
+			comments = append(comments, strings.TrimSpace(rest[2:end]))
+			p.advance(end)
+		case strings.IndexByte(" \t\r\n", rest[0]) != -1:
+			p.advance(1)
+		default:
+			return comments, nil
+		}
+	}
+	return comments, nil
+}
+
+// space skips whitespace and comments and returns them as they are written.
+func (p *stringsParser) space() (string, error) {
+	start := p.pos
+	_, err := p.skip()
+	return p.s[start:p.pos], err
+}
+
+func (p *stringsParser) expect(c byte) error {
+	if _, err := p.skip(); err != nil {
+		return err
+	}
+	if p.eof() || p.s[p.pos] != c {
+		return p.errorf("expected %q", c)
+	}
+	p.advance(1)
+	return nil
+}
+
+// token reads a quoted string, or an unquoted word.
+func (p *stringsParser) token() (string, error) {
+	if _, err := p.skip(); err != nil {
+		return "", err
+	}
+	if p.eof() {
+		return "", p.errorf("unexpected end of file")
+	}
+	if p.s[p.pos] != '"' {
+		end := p.pos
+		for end < len(p.s) && strings.IndexByte(" \t\r\n=;\"", p.s[end]) == -1 {
+			end++
+		}
+		if end == p.pos {
+			return "", p.errorf("unexpected %q", p.s[p.pos])
+		}
+		word := p.s[p.pos:end]
+		p.advance(end - p.pos)
+		return word, nil
+	}
+	var buf bytes.Buffer
+	for i := p.pos + 1; i < len(p.s); i++ {
+		switch c := p.s[i]; c {
+		case '"':
+			p.advance(i + 1 - p.pos)
+			return buf.String(), nil
+		case '\\':
+			if i+1 == len(p.s) {
+				break
+			}
+			i++
+			switch e := p.s[i]; e {
+			case 'n':
+				buf.WriteByte('\n')
+			case 't':
+				buf.WriteByte('\t')
+			case 'r':
+				buf.WriteByte('\r')
+			case '0':
+				buf.WriteByte(0)
+			case 'u', 'U':
+				if i+5 > len(p.s) {
+					return "", p.errorf("invalid unicode escape")
+				}
+				r, err := strconv.ParseUint(p.s[i+1:i+5], 16, 16)
+				if err != nil {
+					return "", p.errorf("invalid unicode escape")
+				}
+				i += 4
+				// characters outside of the BMP are escaped as surrogate pairs
+				if utf16.IsSurrogate(rune(r)) && i+6 < len(p.s) && p.s[i+1] == '\\' && (p.s[i+2] == 'u' || p.s[i+2] == 'U') {
+					if low, err := strconv.ParseUint(p.s[i+3:i+7], 16, 16); err == nil {
+						buf.WriteRune(utf16.DecodeRune(rune(r), rune(low)))
+						i += 6
+						continue
+					}
+				}
+				buf.WriteRune(rune(r))
+			default:
+				buf.WriteByte(e)
+			}
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (c *stringsCodec) decode(b []byte) (*catalog, error) {
+	text, enc, err := decodeText(b, encodingUTF8)
+	if err != nil {
+		return nil, err
+	}
+	p := &stringsParser{s: text, line: 1}
+	cat := &catalog{Encoding: enc}
+	for {
+		start := p.pos
+		comments, err := p.skip()
+		if err != nil {
+			return nil, err
+		}
+		if p.eof() {
+			cat.Trailer = []string{p.s[start:]}
+			return cat, nil
+		}
+		m := &message{Comment: strings.Join(comments, "\n"), Trivia: []string{p.s[start:p.pos]}}
+		if m.Key, err = p.token(); err != nil {
+			return nil, err
+		}
+		if err = p.expectSpaced('=', m); err != nil {
+			return nil, err
+		}
+		space, err := p.space()
+		if err != nil {
+			return nil, err
+		}
+		m.Trivia = append(m.Trivia, space)
+		if m.Value, err = p.token(); err != nil {
+			return nil, err
+		}
+		if err = p.expectSpaced(';', m); err != nil {
+			return nil, err
+		}
+		cat.Messages = append(cat.Messages, m)
+	}
+}
+
+// expectSpaced expects c, and adds the whitespace before it to the trivia
+// of m.
+func (p *stringsParser) expectSpaced(c byte, m *message) error {
+	space, err := p.space()
+	if err != nil {
+		return err
+	}
+	m.Trivia = append(m.Trivia, space)
+	return p.expect(c)
+}
+
+// stringsComment returns the comment in the whitespace before an entry.
+func stringsComment(space string) string {
+	comments, _ := (&stringsParser{s: space}).skip()
+	return strings.Join(comments, "\n")
+}
+
+func (c *stringsCodec) encode(cat *catalog) ([]byte, error) {
+	var buf bytes.Buffer
+	for i, m := range cat.Messages {
+		// the entries that were read are laid out as they were, with the
+		// comment written anew if it changed
+		trivia := []string{"", " ", " ", ""}
+		if len(m.Trivia) == len(trivia) {
+			copy(trivia, m.Trivia)
+		}
+		if len(m.Trivia) != len(trivia) || stringsComment(trivia[0]) != m.Comment {
+			trivia[0] = ""
+			if i > 0 {
+				trivia[0] = "\n\n"
+			}
+			if m.Comment != "" {
+				trivia[0] += "/* " + strings.Replace(m.Comment, "*/", "* /", -1) + " */\n"
+			}
+		}
+		buf.WriteString(trivia[0])
+		buf.WriteString(quoteStrings(m.Key))
+		buf.WriteString(trivia[1] + "=" + trivia[2])
+		buf.WriteString(quoteStrings(m.Value))
+		buf.WriteString(trivia[3] + ";")
+	}
+	if cat.Trailer != nil {
+		buf.WriteString(strings.Join(cat.Trailer, ""))
+	} else if len(cat.Messages) > 0 {
+		buf.WriteByte('\n')
+	}
+	return encodeText(buf.String(), cat.Encoding)
+}
+
+func quoteStrings(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+	return `"` + r.Replace(s) + `"`
+}
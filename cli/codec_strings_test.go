@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestStringsCodec_roundTrip(t *testing.T) {
+	content := `/* greeting */
+"hello" = "Hello \"%@\"";
+
+"lines" = "one\ntwo";
+`
+	cat := testRoundTrip(t, &stringsCodec{}, content)
+	if m := cat.Messages[0]; m.Comment != "greeting" || m.Value != `Hello "%@"` {
+		t.Errorf("decode returned unexpected message %+v", m)
+	}
+	if m := cat.Messages[1]; m.Value != "one\ntwo" {
+		t.Errorf("decode should unescape values, got %+v", m)
+	}
+}
+
+func TestStringsCodec_roundTripLayout(t *testing.T) {
+	content := "// Settings\n\"title\"=\"Settings\";\n\"done\"  =  \"Done\" ;\t// inline\n\"back\"=\"Back\";\n\n/* end */"
+	cat := testRoundTrip(t, &stringsCodec{}, content)
+	if m := cat.Messages[2]; m.Comment != "inline" {
+		t.Errorf("decode returned unexpected message %+v", m)
+	}
+
+	cat.Messages[1].Value = "Fertig"
+	cat.Messages[2].Comment = "Go back"
+	cat.Messages = append(cat.Messages, &message{Key: "new", Value: "Neu"})
+	b, err := (&stringsCodec{}).encode(cat)
+	if err != nil {
+		t.Fatalf("encode returned error %v", err)
+	}
+	want := "// Settings\n\"title\"=\"Settings\";\n\"done\"  =  \"Fertig\" ;\n\n/* Go back */\n\"back\"=\"Back\";\n\n\"new\" = \"Neu\";\n\n/* end */"
+	if string(b) != want {
+		t.Errorf("encode should keep the layout of unchanged entries, expected %q, got %q", want, string(b))
+	}
+}
+
+func TestStringsCodec_decode(t *testing.T) {
+	content := "\ufeff// comment\nunquoted = \"\\U00e9\\ud83d\\ude00\";"
+	cat, err := (&stringsCodec{}).decode([]byte(content))
+	if err != nil {
+		t.Fatalf("decode returned error %v", err)
+	}
+	if m := cat.Messages[0]; m.Key != "unquoted" || m.Value != "é😀" || m.Comment != "comment" {
+		t.Errorf("decode returned unexpected message %+v", m)
+	}
+}
+
+func TestStringsCodec_error(t *testing.T) {
+	_, err := (&stringsCodec{}).decode([]byte("\"a\" = \"b\";\n\"c\" = \"d\""))
+	if err == nil || err.Error() != `line 2: expected ';'` {
+		t.Errorf("decode should report missing semicolons, got %v", err)
+	}
+}
+
+func TestStringsCodec_utf16(t *testing.T) {
+	content, _ := encodeText("/* comment */\n\"hello\" = \"héllo\";\n", encodingUTF16LE)
+	cat, err := (&stringsCodec{}).decode(content)
+	if err != nil {
+		t.Fatalf("decode returned error %v", err)
+	}
+	if m := cat.Messages[0]; m.Value != "héllo" || m.Comment != "comment" {
+		t.Errorf("decode returned unexpected message %+v", m)
+	}
+	b, err := (&stringsCodec{}).encode(cat)
+	if err != nil {
+		t.Fatalf("encode returned error %v", err)
+	}
+	if !bytes.Equal(b, content) {
+		t.Errorf("encode should keep the encoding, expected %v, got %v", content, b)
+	}
+}
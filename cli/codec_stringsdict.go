@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	stringsdictFormatKey  = "NSStringLocalizedFormatKey"
+	stringsdictSpecType   = "NSStringFormatSpecTypeKey"
+	stringsdictValueType  = "NSStringFormatValueTypeKey"
+	stringsdictPluralRule = "NSStringPluralRuleType"
+	plistHeader           = xml.Header + `<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n"
+)
+
+// stringsdictCodec reads and writes iOS and OS X .stringsdict files. The
+// format key of an entry is kept as the value of its message, and every
+// variable it references becomes one of its variables.
+type stringsdictCodec struct{}
+
+// plistEntry is an entry of a property list dictionary. Its value is either
+// a string or a nested dictionary.
+type plistEntry struct {
+	key   string
+	value string
+	dict  []plistEntry
+}
+
+func (c *stringsdictCodec) decode(b []byte) (*catalog, error) {
+	d := xml.NewDecoder(bytes.NewReader(b))
+	root, err := decodePlist(d)
+	if err != nil {
+		return nil, err
+	}
+	cat := new(catalog)
+	for _, entry := range root {
+		if entry.dict == nil {
+			return nil, fmt.Errorf("Entry %s must be a dictionary", entry.key)
+		}
+		m := &message{Key: entry.key}
+		for _, field := range entry.dict {
+			if field.key == stringsdictFormatKey {
+				m.Value = field.value
+				continue
+			}
+			if field.dict == nil {
+				return nil, fmt.Errorf("Variable %s of entry %s must be a dictionary", field.key, entry.key)
+			}
+			v := &variable{Name: field.key, Plurals: make(map[string]string)}
+			for _, rule := range field.dict {
+				switch rule.key {
+				case stringsdictSpecType:
+					if rule.value != stringsdictPluralRule {
+						return nil, fmt.Errorf("Variable %s of entry %s has unsupported type %s", field.key, entry.key, rule.value)
+					}
+				case stringsdictValueType:
+					v.ValueType = rule.value
+				default:
+					v.Plurals[rule.key] = rule.value
+				}
+			}
+			m.Variables = append(m.Variables, v)
+		}
+		cat.Messages = append(cat.Messages, m)
+	}
+	return cat, nil
+}
+
+// decodePlist returns the entries of the root dictionary of a property list.
+func decodePlist(d *xml.Decoder) ([]plistEntry, error) {
+	for {
+		t, err := d.Token()
+		if err == io.EOF {
+			return nil, errors.New("Missing <dict> element")
+		} else if err != nil {
+			return nil, err
+		}
+		if start, ok := t.(xml.StartElement); ok && start.Name.Local == "dict" {
+			return decodePlistDict(d)
+		}
+	}
+}
+
+func decodePlistDict(d *xml.Decoder) ([]plistEntry, error) {
+	entries := []plistEntry{}
+	var key *string
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := t.(type) {
+		case xml.StartElement:
+			var entry plistEntry
+			switch t.Name.Local {
+			case "key":
+				var s string
+				if err = d.DecodeElement(&s, &t); err != nil {
+					return nil, err
+				}
+				key = &s
+				continue
+			case "dict":
+				if entry.dict, err = decodePlistDict(d); err != nil {
+					return nil, err
+				}
+			case "string":
+				if err = d.DecodeElement(&entry.value, &t); err != nil {
+					return nil, err
+				}
+			default:
+				return nil, fmt.Errorf("Unsupported element <%s>", t.Name.Local)
+			}
+			if key == nil {
+				return nil, fmt.Errorf("Missing <key> before <%s>", t.Name.Local)
+			}
+			entry.key = *key
+			key = nil
+			entries = append(entries, entry)
+		case xml.EndElement:
+			if key != nil {
+				return nil, fmt.Errorf("Missing value of key %s", *key)
+			}
+			return entries, nil
+		}
+	}
+}
+
+func (c *stringsdictCodec) encode(cat *catalog) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(plistHeader)
+	buf.WriteString("<plist version=\"1.0\">\n<dict>\n")
+	for _, m := range cat.Messages {
+		writePlistKey(&buf, 1, m.Key)
+		buf.WriteString("\t<dict>\n")
+		writePlistKey(&buf, 2, stringsdictFormatKey)
+		writePlistString(&buf, 2, m.Value)
+		for _, v := range m.Variables {
+			writePlistKey(&buf, 2, v.Name)
+			buf.WriteString("\t\t<dict>\n")
+			writePlistKey(&buf, 3, stringsdictSpecType)
+			writePlistString(&buf, 3, stringsdictPluralRule)
+			if v.ValueType != "" {
+				writePlistKey(&buf, 3, stringsdictValueType)
+				writePlistString(&buf, 3, v.ValueType)
+			}
+			for _, category := range pluralKeys(v.Plurals) {
+				writePlistKey(&buf, 3, category)
+				writePlistString(&buf, 3, v.Plurals[category])
+			}
+			buf.WriteString("\t\t</dict>\n")
+		}
+		buf.WriteString("\t</dict>\n")
+	}
+	buf.WriteString("</dict>\n</plist>\n")
+	return buf.Bytes(), nil
+}
+
+// plistEscaper escapes only what XML requires, like Xcode does.
+var plistEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func writePlistKey(buf *bytes.Buffer, depth int, key string) {
+	buf.WriteString(strings.Repeat("\t", depth) + "<key>" + plistEscaper.Replace(key) + "</key>\n")
+}
+
+func writePlistString(buf *bytes.Buffer, depth int, s string) {
+	buf.WriteString(strings.Repeat("\t", depth) + "<string>" + plistEscaper.Replace(s) + "</string>\n")
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"testing"
+)
+
+func TestStringsdictCodec_roundTrip(t *testing.T) {
+	content := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>%d files in %d folders</key>
+	<dict>
+		<key>NSStringLocalizedFormatKey</key>
+		<string>%#@files@ in %#@folders@</string>
+		<key>files</key>
+		<dict>
+			<key>NSStringFormatSpecTypeKey</key>
+			<string>NSStringPluralRuleType</string>
+			<key>NSStringFormatValueTypeKey</key>
+			<string>d</string>
+			<key>one</key>
+			<string>%d file</string>
+			<key>other</key>
+			<string>%d files</string>
+		</dict>
+		<key>folders</key>
+		<dict>
+			<key>NSStringFormatSpecTypeKey</key>
+			<string>NSStringPluralRuleType</string>
+			<key>NSStringFormatValueTypeKey</key>
+			<string>d</string>
+			<key>one</key>
+			<string>one folder</string>
+			<key>other</key>
+			<string>%d folders &amp; "more"</string>
+		</dict>
+	</dict>
+	<key>a</key>
+	<dict>
+		<key>NSStringLocalizedFormatKey</key>
+		<string>%#@n@</string>
+	</dict>
+</dict>
+</plist>
+`
+	cat := testRoundTrip(t, &stringsdictCodec{}, content)
+	if len(cat.Messages) != 2 {
+		t.Fatalf("decode expected 2 messages, got %d", len(cat.Messages))
+	}
+	m := cat.Messages[0]
+	if m.Key != "%d files in %d folders" || m.Value != "%#@files@ in %#@folders@" || len(m.Variables) != 2 {
+		t.Fatalf("decode returned unexpected message %+v", m)
+	}
+	if v := m.Variables[1]; v.Name != "folders" || v.ValueType != "d" || v.Plurals["other"] != `%d folders & "more"` {
+		t.Errorf("decode returned unexpected variable %+v", v)
+	}
+	if keys := cat.flatten(); keys["%d files in %d folders.files.one"] != "%d file" {
+		t.Errorf("flatten should include the plural forms of variables, got %v", keys)
+	}
+}
+
+func TestStringsdictCodec_error(t *testing.T) {
+	tests := []string{
+		`<plist></plist>`,
+		`<plist><dict><key>a</key><string>b</string></dict></plist>`,
+		`<plist><dict><key>a</key><dict><key>v</key><dict><key>NSStringFormatSpecTypeKey</key><string>Other</string></dict></dict></dict></plist>`,
+		`<plist><dict><key>a</key></dict></plist>`,
+		`<plist><dict><string>b</string></dict></plist>`,
+		`<plist><dict><key>a</key><array/></dict></plist>`,
+	}
+	for _, content := range tests {
+		if _, err := (&stringsdictCodec{}).decode([]byte(content)); err == nil {
+			t.Errorf("decode should return an error for %s", content)
+		}
+	}
+}
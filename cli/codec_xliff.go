@@ -0,0 +1,292 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+const (
+	xliffVersion12 = "1.2"
+	xliffVersion20 = "2.0"
+)
+
+// xliffCodec reads and writes XLIFF 1.2 and 2.0 files. Source and target
+// strings are kept as XML fragments, so that inline tags like <x/> and <ph>
+// survive a round trip. Notes are kept as the comment of their unit, and
+// the state of the target as the state of the message. The identifiers of
+// files and units are kept, so that translated files can be matched with
+// the ones sent to translators.
+//
+// Translations that still need to be reviewed are unverified, like
+// Translation.Unverified in PhraseApp: in XLIFF 1.2 those whose target is
+// new or needs review and whose unit is not approved, in XLIFF 2.0 those
+// whose segment is in the initial or translated state.
+type xliffCodec struct{}
+
+type xliffDocument struct {
+	XMLName        xml.Name    `xml:"xliff"`
+	Version        string      `xml:"version,attr"`
+	SourceLanguage string      `xml:"srcLang,attr"`
+	TargetLanguage string      `xml:"trgLang,attr"`
+	Files          []xliffFile `xml:"file"`
+}
+
+// xliffFile is a <file> of either version. XLIFF 1.2 keeps its units in
+// a <body>, XLIFF 2.0 directly in the file.
+type xliffFile struct {
+	ID             string       `xml:"id,attr"`
+	Original       string       `xml:"original,attr"`
+	DataType       string       `xml:"datatype,attr"`
+	SourceLanguage string       `xml:"source-language,attr"`
+	TargetLanguage string       `xml:"target-language,attr"`
+	Body           xliffGroup   `xml:"body"`
+	Units          []xliffUnit  `xml:"unit"`
+	Groups         []xliffGroup `xml:"group"`
+}
+
+type xliffGroup struct {
+	TransUnits []xliffUnit  `xml:"trans-unit"`
+	Units      []xliffUnit  `xml:"unit"`
+	Groups     []xliffGroup `xml:"group"`
+}
+
+// xliffUnit is a <trans-unit> of XLIFF 1.2 or a <unit> of XLIFF 2.0.
+type xliffUnit struct {
+	ID       string         `xml:"id,attr"`
+	Name     string         `xml:"name,attr"`
+	ResName  string         `xml:"resname,attr"`
+	Approved string         `xml:"approved,attr"`
+	Source   xliffContent   `xml:"source"`
+	Target   xliffContent   `xml:"target"`
+	Notes    []string       `xml:"note"`
+	Notes20  []string       `xml:"notes>note"`
+	Segments []xliffSegment `xml:"segment"`
+}
+
+type xliffSegment struct {
+	State  string       `xml:"state,attr"`
+	Source xliffContent `xml:"source"`
+	Target xliffContent `xml:"target"`
+}
+
+type xliffContent struct {
+	State string `xml:"state,attr"`
+	Inner string `xml:",innerxml"`
+}
+
+func (c *xliffCodec) decode(b []byte) (*catalog, error) {
+	cats, err := decodeXliffFiles(b)
+	if err != nil {
+		return nil, err
+	}
+	cat := cats[0]
+	for _, other := range cats[1:] {
+		cat.Messages = append(cat.Messages, other.Messages...)
+	}
+	return cat, nil
+}
+
+// decodeXliffFiles returns a catalog for every <file> of an XLIFF document.
+// Documents of agencies often bundle the files of several locales.
+func decodeXliffFiles(b []byte) ([]*catalog, error) {
+	var doc xliffDocument
+	if err := xml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	version := xliffVersion12
+	if strings.HasPrefix(doc.Version, "2.") {
+		version = doc.Version
+	} else if doc.Version != "" && doc.Version != xliffVersion12 {
+		return nil, fmt.Errorf("Unsupported XLIFF version %s", doc.Version)
+	}
+	if len(doc.Files) == 0 {
+		return []*catalog{{Version: version, Locale: doc.TargetLanguage, SourceLocale: doc.SourceLanguage}}, nil
+	}
+	var cats []*catalog
+	for _, file := range doc.Files {
+		cat := &catalog{Version: version, Original: file.Original, DataType: file.DataType, FileID: file.ID}
+		if version == xliffVersion12 {
+			cat.Locale, cat.SourceLocale = file.TargetLanguage, file.SourceLanguage
+			cat.Messages = decodeXliffGroup(file.Body, version)
+		} else {
+			cat.Locale, cat.SourceLocale = doc.TargetLanguage, doc.SourceLanguage
+			cat.Messages = decodeXliffGroup(xliffGroup{Units: file.Units, Groups: file.Groups}, version)
+		}
+		cats = append(cats, cat)
+	}
+	return cats, nil
+}
+
+func decodeXliffGroup(group xliffGroup, version string) []*message {
+	var messages []*message
+	for _, unit := range append(group.TransUnits, group.Units...) {
+		messages = append(messages, decodeXliffUnit(unit, version))
+	}
+	for _, child := range group.Groups {
+		messages = append(messages, decodeXliffGroup(child, version)...)
+	}
+	return messages
+}
+
+func decodeXliffUnit(unit xliffUnit, version string) *message {
+	m := &message{Key: unit.ResName}
+	if version == xliffVersion12 {
+		m.Source, m.Value, m.State = unit.Source.Inner, unit.Target.Inner, unit.Target.State
+		m.Comment = strings.Join(unit.Notes, "\n")
+		m.Unverified = unit.Approved != "yes" && (m.State == "new" || strings.HasPrefix(m.State, "needs-"))
+	} else {
+		m.Key = unit.Name
+		// segments of a unit are joined, their boundaries are not kept
+		for i, segment := range unit.Segments {
+			m.Source += segment.Source.Inner
+			m.Value += segment.Target.Inner
+			if i == 0 {
+				m.State = segment.State
+			}
+		}
+		m.Comment = strings.Join(unit.Notes20, "\n")
+		m.Unverified = m.State == "initial" || m.State == "translated"
+	}
+	if m.Key == "" {
+		m.Key = unit.ID
+	} else {
+		m.ID = unit.ID
+	}
+	return m
+}
+
+func (c *xliffCodec) encode(cat *catalog) ([]byte, error) {
+	return encodeXliffFiles([]*catalog{cat})
+}
+
+// encodeXliffFiles writes the catalogs as the files of one XLIFF document,
+// in the version of the first catalog. XLIFF 2.0 documents have a single
+// target language, so all catalogs must belong to the same locale.
+func encodeXliffFiles(cats []*catalog) ([]byte, error) {
+	version := cats[0].Version
+	if version == "" {
+		version = xliffVersion12
+	}
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	if version == xliffVersion12 {
+		buf.WriteString(`<xliff version="1.2" xmlns="urn:oasis:names:tc:xliff:document:1.2">` + "\n")
+		for _, cat := range cats {
+			encodeXliff12File(&buf, cat)
+		}
+		buf.WriteString("</xliff>\n")
+		return buf.Bytes(), nil
+	}
+
+	if cats[0].SourceLocale == "" {
+		return nil, fmt.Errorf("XLIFF %s documents must have a source locale", version)
+	}
+	buf.WriteString(`<xliff version="` + xmlEscape(version) + `" xmlns="urn:oasis:names:tc:xliff:document:` + xmlEscape(version) + `"`)
+	buf.WriteString(` srcLang="` + xmlEscape(cats[0].SourceLocale) + `"`)
+	if cats[0].Locale != "" {
+		buf.WriteString(` trgLang="` + xmlEscape(cats[0].Locale) + `"`)
+	}
+	buf.WriteString(">\n")
+	ids := make(map[string]bool)
+	for i, cat := range cats {
+		if cat.Locale != cats[0].Locale {
+			return nil, fmt.Errorf("XLIFF %s documents cannot contain both %s and %s", version, cats[0].Locale, cat.Locale)
+		}
+		// files merged from several documents may have the same id
+		id := cat.FileID
+		if id == "" || ids[id] {
+			id = fmt.Sprintf("f%d", i+1)
+		}
+		ids[id] = true
+		encodeXliff20File(&buf, cat, id)
+	}
+	buf.WriteString("</xliff>\n")
+	return buf.Bytes(), nil
+}
+
+// xliffUnitID returns the id and the name of the unit of a message. Units
+// are named by their key if they had an id of their own.
+func xliffUnitID(m *message) (string, string) {
+	if m.ID == "" {
+		return m.Key, ""
+	}
+	return m.ID, m.Key
+}
+
+func encodeXliff12File(buf *bytes.Buffer, cat *catalog) {
+	original, dataType := cat.Original, cat.DataType
+	if original == "" {
+		original = "phrase"
+	}
+	if dataType == "" {
+		dataType = "plaintext"
+	}
+	buf.WriteString(`  <file original="` + xmlEscape(original) + `" datatype="` + xmlEscape(dataType) + `" source-language="` + xmlEscape(cat.SourceLocale) + `"`)
+	if cat.Locale != "" {
+		buf.WriteString(` target-language="` + xmlEscape(cat.Locale) + `"`)
+	}
+	buf.WriteString(">\n    <body>\n")
+	for _, m := range cat.Messages {
+		id, name := xliffUnitID(m)
+		buf.WriteString(`      <trans-unit id="` + xmlEscape(id) + `"`)
+		if name != "" {
+			buf.WriteString(` resname="` + xmlEscape(name) + `"`)
+		}
+		buf.WriteString(">\n")
+		buf.WriteString("        <source>" + m.Source + "</source>\n")
+		state := m.State
+		if unverified := state == "new" || strings.HasPrefix(state, "needs-"); unverified != m.Unverified {
+			state = "translated"
+			if m.Unverified {
+				state = "needs-review-translation"
+			}
+		}
+		if state != "" {
+			buf.WriteString(`        <target state="` + xmlEscape(state) + `">` + m.Value + "</target>\n")
+		} else {
+			buf.WriteString("        <target>" + m.Value + "</target>\n")
+		}
+		if m.Comment != "" {
+			buf.WriteString("        <note>" + xmlEscape(m.Comment) + "</note>\n")
+		}
+		buf.WriteString("      </trans-unit>\n")
+	}
+	buf.WriteString("    </body>\n  </file>\n")
+}
+
+func encodeXliff20File(buf *bytes.Buffer, cat *catalog, id string) {
+	buf.WriteString(`  <file id="` + xmlEscape(id) + `"`)
+	if cat.Original != "" {
+		buf.WriteString(` original="` + xmlEscape(cat.Original) + `"`)
+	}
+	buf.WriteString(">\n")
+	for _, m := range cat.Messages {
+		id, name := xliffUnitID(m)
+		buf.WriteString(`    <unit id="` + xmlEscape(id) + `"`)
+		if name != "" {
+			buf.WriteString(` name="` + xmlEscape(name) + `"`)
+		}
+		buf.WriteString(">\n")
+		if m.Comment != "" {
+			buf.WriteString("      <notes>\n        <note>" + xmlEscape(m.Comment) + "</note>\n      </notes>\n")
+		}
+		state := m.State
+		if unverified := state == "initial" || state == "translated"; unverified != m.Unverified {
+			state = "reviewed"
+			if m.Unverified {
+				state = "translated"
+			}
+		}
+		if state != "" {
+			buf.WriteString(`      <segment state="` + xmlEscape(state) + "\">\n")
+		} else {
+			buf.WriteString("      <segment>\n")
+		}
+		buf.WriteString("        <source>" + m.Source + "</source>\n")
+		buf.WriteString("        <target>" + m.Value + "</target>\n")
+		buf.WriteString("      </segment>\n    </unit>\n")
+	}
+	buf.WriteString("  </file>\n")
+}
@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestXliffCodec_roundTrip(t *testing.T) {
+	content := `<?xml version="1.0" encoding="UTF-8"?>
+<xliff version="1.2" xmlns="urn:oasis:names:tc:xliff:document:1.2">
+  <file original="phrase" datatype="plaintext" source-language="en" target-language="de">
+    <body>
+      <trans-unit id="hello">
+        <source>Hello <x id="1"/></source>
+        <target>Hallo <x id="1"/></target>
+        <note>greeting</note>
+      </trans-unit>
+    </body>
+  </file>
+</xliff>
+`
+	cat := testRoundTrip(t, &xliffCodec{}, content)
+	if cat.Locale != "de" || cat.SourceLocale != "en" {
+		t.Errorf("decode should read the languages, got %q and %q", cat.Locale, cat.SourceLocale)
+	}
+	if m := cat.Messages[0]; m.Key != "hello" || m.Value != `Hallo <x id="1"/>` || m.Comment != "greeting" {
+		t.Errorf("decode returned unexpected message %+v", m)
+	}
+}
+
+func TestXliffCodec_resname(t *testing.T) {
+	content := `<xliff version="1.2"><file><body><trans-unit id="1" resname="app.title"><source>Title</source></trans-unit></body></file></xliff>`
+	cat, err := (&xliffCodec{}).decode([]byte(content))
+	if err != nil {
+		t.Fatalf("decode returned error %v", err)
+	}
+	if m := cat.Messages[0]; m.Key != "app.title" || m.Source != "Title" {
+		t.Errorf("decode should prefer resname over id, got %+v", m)
+	}
+}
+
+func TestXliffCodec_identifiers(t *testing.T) {
+	content := `<?xml version="1.0" encoding="UTF-8"?>
+<xliff version="1.2" xmlns="urn:oasis:names:tc:xliff:document:1.2">
+  <file original="app.strings" datatype="x-strings" source-language="en" target-language="de">
+    <body>
+      <trans-unit id="42" resname="hello">
+        <source>Hello</source>
+        <target>Hallo</target>
+      </trans-unit>
+    </body>
+  </file>
+</xliff>
+`
+	cat := testRoundTrip(t, &xliffCodec{}, content)
+	if m := cat.Messages[0]; m.Key != "hello" || m.ID != "42" {
+		t.Errorf("decode should keep the id of units, got %+v", m)
+	}
+
+	content = `<?xml version="1.0" encoding="UTF-8"?>
+<xliff version="2.0" xmlns="urn:oasis:names:tc:xliff:document:2.0" srcLang="en" trgLang="de">
+  <file id="main" original="app.strings">
+    <unit id="u1" name="hello">
+      <segment>
+        <source>Hello</source>
+        <target>Hallo</target>
+      </segment>
+    </unit>
+  </file>
+</xliff>
+`
+	cat = testRoundTrip(t, &xliffCodec{}, content)
+	cat.SourceLocale = ""
+	if _, err := (&xliffCodec{}).encode(cat); err == nil {
+		t.Error("encode should return an error for XLIFF 2.0 documents without source locale")
+	}
+}
+
+func TestXliffCodec_version20(t *testing.T) {
+	content := `<?xml version="1.0" encoding="UTF-8"?>
+<xliff version="2.0" xmlns="urn:oasis:names:tc:xliff:document:2.0" srcLang="en" trgLang="fr">
+  <file id="f1">
+    <unit id="hello">
+      <notes>
+        <note>greeting</note>
+      </notes>
+      <segment state="reviewed">
+        <source>Hello <ph id="1"/></source>
+        <target>Bonjour <ph id="1"/></target>
+      </segment>
+    </unit>
+    <unit id="bye">
+      <segment state="translated">
+        <source>Bye</source>
+        <target>Au revoir</target>
+      </segment>
+    </unit>
+  </file>
+</xliff>
+`
+	cat := testRoundTrip(t, &xliffCodec{}, content)
+	if cat.Version != "2.0" || cat.Locale != "fr" || cat.SourceLocale != "en" {
+		t.Errorf("decode returned unexpected catalog %+v", cat)
+	}
+	if m := cat.Messages[0]; m.Value != `Bonjour <ph id="1"/>` || m.Comment != "greeting" || m.Unverified {
+		t.Errorf("decode returned unexpected message %+v", m)
+	}
+	if m := cat.Messages[1]; !m.Unverified {
+		t.Errorf("decode should read translated segments as unverified, got %+v", m)
+	}
+}
+
+func TestXliffCodec_state(t *testing.T) {
+	content := `<xliff version="1.2"><file target-language="de"><body>
+<trans-unit id="a"><source>A</source><target state="needs-review-translation">A</target></trans-unit>
+<trans-unit id="b" approved="yes"><source>B</source><target state="needs-review-translation">B</target></trans-unit>
+<group><trans-unit id="c"><source>C</source><target state="final">C</target></trans-unit></group>
+</body></file></xliff>`
+	c := &xliffCodec{}
+	cat, err := c.decode([]byte(content))
+	if err != nil {
+		t.Fatalf("decode returned error %v", err)
+	}
+	want := map[string]bool{"a": true, "b": false, "c": false}
+	if len(cat.Messages) != len(want) {
+		t.Fatalf("decode expected %d messages, got %d", len(want), len(cat.Messages))
+	}
+	for _, m := range cat.Messages {
+		if m.Unverified != want[m.Key] {
+			t.Errorf("decode expected %s to be unverified: %v", m.Key, want[m.Key])
+		}
+	}
+
+	// changing the verification changes the state
+	cat.Messages[2].Unverified = true
+	b, err := c.encode(cat)
+	if err != nil {
+		t.Fatalf("encode returned error %v", err)
+	}
+	if !strings.Contains(string(b), `<target state="needs-review-translation">C</target>`) {
+		t.Errorf("encode should write the state of unverified translations, got %s", b)
+	}
+}
+
+func TestXliffFiles(t *testing.T) {
+	content := `<xliff version="1.2">
+<file source-language="en" target-language="de"><body><trans-unit id="a"><source>A</source></trans-unit></body></file>
+<file source-language="en" target-language="fr"><body><trans-unit id="b"><source>B</source></trans-unit></body></file>
+</xliff>`
+	cats, err := decodeXliffFiles([]byte(content))
+	if err != nil {
+		t.Fatalf("decodeXliffFiles returned error %v", err)
+	}
+	if len(cats) != 2 || cats[0].Locale != "de" || cats[1].Locale != "fr" {
+		t.Fatalf("decodeXliffFiles should return a catalog per file, got %+v", cats)
+	}
+	b, err := encodeXliffFiles(cats)
+	if err != nil {
+		t.Fatalf("encodeXliffFiles returned error %v", err)
+	}
+	if again, _ := decodeXliffFiles(b); len(again) != 2 || again[1].Messages[0].Key != "b" {
+		t.Errorf("encodeXliffFiles should write every file, got %s", b)
+	}
+
+	cats[0].Version, cats[1].Version = "2.0", "2.0"
+	if _, err = encodeXliffFiles(cats); err == nil {
+		t.Error("encodeXliffFiles should not mix locales in XLIFF 2.0 documents")
+	}
+	if _, err = decodeXliffFiles([]byte(`<xliff version="3.0"></xliff>`)); err == nil {
+		t.Error("decodeXliffFiles should reject unknown versions")
+	}
+}
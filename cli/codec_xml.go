@@ -0,0 +1,374 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/weynsee/go-phrase/phrase"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// androidCodec reads and writes Android string resources (strings.xml).
+// Values are kept as XML fragments, so that inline markup, CDATA sections
+// and escaping survive a round trip untouched. Comments right above a
+// resource are kept as its comment, and resources marked with
+// translatable="false" get the untranslatable flag. The attributes of
+// resources, and resources that are not translations, like <color>, are
+// kept as they are written.
+type androidCodec struct{}
+
+// unformattedFlag marks Android strings with formatted="false", whose
+// percent signs are not format specifiers.
+const unformattedFlag = "unformatted"
+
+func (c *androidCodec) decode(b []byte) (*catalog, error) {
+	d := xml.NewDecoder(bytes.NewReader(b))
+	cat := new(catalog)
+	var comment string
+	inResources := false
+	for {
+		offset := d.InputOffset()
+		t, err := d.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		switch t := t.(type) {
+		case xml.Comment:
+			comment = strings.TrimSpace(string(t))
+		case xml.StartElement:
+			if !inResources {
+				if t.Name.Local != "resources" {
+					return nil, fmt.Errorf("Unexpected element <%s>, expected <resources>", t.Name.Local)
+				}
+				inResources = true
+				cat.Header = string(b[:d.InputOffset()])
+				comment = ""
+				continue
+			}
+			m := &message{Key: xmlAttr(t, "name"), Comment: comment}
+			comment = ""
+			if xmlAttr(t, "translatable") == "false" {
+				m.Flags = append(m.Flags, untranslatableFlag)
+			}
+			if xmlAttr(t, "formatted") == "false" {
+				m.Flags = append(m.Flags, unformattedFlag)
+			}
+			m.Attributes = xmlRawAttributes(string(b[offset:d.InputOffset()]))
+			switch t.Name.Local {
+			case "string":
+				if m.Value, err = xmlInner(d, b); err != nil {
+					return nil, err
+				}
+			case "plurals":
+				items, err := xmlItems(d, b, "quantity")
+				if err != nil {
+					return nil, err
+				}
+				m.Plurals = make(map[string]string)
+				for _, item := range items {
+					m.Plurals[item.attr] = item.value
+				}
+			case "string-array":
+				items, err := xmlItems(d, b, "")
+				if err != nil {
+					return nil, err
+				}
+				m.Array = make([]string, len(items))
+				for i, item := range items {
+					m.Array[i] = item.value
+				}
+			default:
+				// other resource types are not translations
+				if err = d.Skip(); err != nil {
+					return nil, err
+				}
+				m = &message{Raw: string(b[offset:d.InputOffset()]), Comment: m.Comment}
+			}
+			cat.Messages = append(cat.Messages, m)
+		case xml.CharData:
+			if len(bytes.TrimSpace(t)) > 0 {
+				comment = ""
+			}
+		}
+	}
+	if !inResources {
+		return nil, errors.New("Missing <resources> element")
+	}
+	return cat, nil
+}
+
+// xmlRawAttributes returns the attributes of a start tag as they are
+// written, with the space before them.
+func xmlRawAttributes(tag string) string {
+	tag = strings.TrimSuffix(strings.TrimSuffix(tag, ">"), "/")
+	if i := strings.IndexAny(tag, " \t\r\n"); i != -1 {
+		return strings.TrimRight(tag[i:], " \t\r\n")
+	}
+	return ""
+}
+
+func xmlAttr(e xml.StartElement, name string) string {
+	for _, attr := range e.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// xmlInner returns the raw content of the element that was just started,
+// and consumes its end element.
+func xmlInner(d *xml.Decoder, b []byte) (string, error) {
+	start := d.InputOffset()
+	depth := 0
+	for {
+		end := d.InputOffset()
+		t, err := d.Token()
+		if err != nil {
+			return "", err
+		}
+		switch t.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			if depth == 0 {
+				return string(b[start:end]), nil
+			}
+			depth--
+		}
+	}
+}
+
+type xmlItem struct {
+	attr  string
+	value string
+}
+
+// xmlItems returns the raw content and the given attribute of all <item>
+// children of the element that was just started.
+func xmlItems(d *xml.Decoder, b []byte, attr string) ([]xmlItem, error) {
+	var items []xmlItem
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := t.(type) {
+		case xml.StartElement:
+			if t.Name.Local != "item" {
+				if err = d.Skip(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			value, err := xmlInner(d, b)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, xmlItem{xmlAttr(t, attr), value})
+		case xml.EndElement:
+			return items, nil
+		}
+	}
+}
+
+func (c *androidCodec) encode(cat *catalog) ([]byte, error) {
+	var buf bytes.Buffer
+	if cat.Header != "" {
+		buf.WriteString(cat.Header + "\n")
+	} else {
+		buf.WriteString(xml.Header)
+		buf.WriteString("<resources>\n")
+	}
+	for _, m := range cat.Messages {
+		if m.Comment != "" {
+			buf.WriteString("  <!-- " + strings.Replace(m.Comment, "--", "- -", -1) + " -->\n")
+		}
+		attrs := m.Attributes
+		if attrs == "" {
+			attrs = ` name="` + xmlEscape(m.Key) + `"`
+			if m.hasFlag(untranslatableFlag) {
+				attrs += ` translatable="false"`
+			}
+			if m.hasFlag(unformattedFlag) {
+				attrs += ` formatted="false"`
+			}
+		}
+		switch {
+		case m.Raw != "":
+			buf.WriteString("  " + m.Raw + "\n")
+		case m.Plurals != nil:
+			buf.WriteString("  <plurals" + attrs + ">\n")
+			for _, category := range pluralKeys(m.Plurals) {
+				buf.WriteString(`    <item quantity="` + xmlEscape(category) + `">` + m.Plurals[category] + "</item>\n")
+			}
+			buf.WriteString("  </plurals>\n")
+		case m.Array != nil:
+			buf.WriteString("  <string-array" + attrs + ">\n")
+			for _, item := range m.Array {
+				buf.WriteString("    <item>" + item + "</item>\n")
+			}
+			buf.WriteString("  </string-array>\n")
+		default:
+			buf.WriteString("  <string" + attrs + ">" + m.Value + "</string>\n")
+		}
+	}
+	buf.WriteString("</resources>\n")
+	return buf.Bytes(), nil
+}
+
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// validate checks that the strings follow the escaping rules of Android,
+// and that plurals have the quantities the locale uses. These mistakes
+// would otherwise only be caught by the Android build.
+func (c *androidCodec) validate(cat *catalog, locale *phrase.Locale) []error {
+	var errs []error
+	for _, m := range cat.Messages {
+		if m.hasFlag(untranslatableFlag) || m.Raw != "" {
+			continue
+		}
+		values := m.Array
+		switch {
+		case m.Plurals != nil:
+			values = nil
+			for _, category := range pluralKeys(m.Plurals) {
+				if !isPluralCategory(category) {
+					errs = append(errs, fmt.Errorf("Key %s has invalid quantity %s", m.Key, category))
+				}
+				values = append(values, m.Plurals[category])
+			}
+			var missing []string
+			for category := range locale.Pluralizations {
+				if _, ok := m.Plurals[category]; !ok {
+					missing = append(missing, category)
+				}
+			}
+			if len(missing) > 0 {
+				sort.Strings(missing)
+				errs = append(errs, fmt.Errorf("Key %s is missing the quantities %s", m.Key, strings.Join(missing, ", ")))
+			}
+		case m.Array == nil:
+			values = []string{m.Value}
+		}
+		for _, value := range values {
+			if err := validateAndroidString(value, !m.hasFlag(unformattedFlag)); err != nil {
+				errs = append(errs, fmt.Errorf("Key %s: %s", m.Key, err.Error()))
+			}
+		}
+	}
+	return errs
+}
+
+var androidFormatSpecifier = regexp.MustCompile(`%(\d+\$)?[-#+ 0,(]*\d*(\.\d+)?[a-zA-Z%]`)
+
+func validateAndroidString(raw string, formatted bool) error {
+	if _, err := androidUnescape(raw); err != nil {
+		return err
+	}
+	if !formatted {
+		return nil
+	}
+	positional, plain := 0, 0
+	for _, match := range androidFormatSpecifier.FindAllStringSubmatch(raw, -1) {
+		switch {
+		case strings.HasSuffix(match[0], "%"):
+			// %% is a literal percent sign
+		case match[1] != "":
+			positional++
+		default:
+			plain++
+		}
+	}
+	if plain > 1 || (plain > 0 && positional > 0) {
+		return errors.New("multiple substitutions must use positional arguments like %1$s")
+	}
+	return nil
+}
+
+// androidUnescape returns the text of an Android string resource, given
+// as an XML fragment, the way Android displays it: markup is dropped,
+// escape sequences are replaced, whitespace outside of double quotes is
+// collapsed and the double quotes themselves are removed.
+func androidUnescape(raw string) (string, error) {
+	d := xml.NewDecoder(strings.NewReader("<string>" + raw + "</string>"))
+	var text bytes.Buffer
+	for {
+		t, err := d.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return "", err
+		}
+		// CDATA sections are reported as character data too
+		if data, ok := t.(xml.CharData); ok {
+			text.Write(data)
+		}
+	}
+
+	var buf bytes.Buffer
+	s := text.String()
+	quoted, space := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !quoted && strings.IndexByte(" \t\r\n", c) != -1 {
+			space = true
+			continue
+		}
+		if space && buf.Len() > 0 {
+			buf.WriteByte(' ')
+		}
+		space = false
+		switch c {
+		case '"':
+			quoted = !quoted
+		case '\'':
+			if !quoted {
+				return "", errors.New("apostrophes must be escaped as \\' or the string must be quoted")
+			}
+			buf.WriteByte(c)
+		case '\\':
+			i++
+			if i == len(s) {
+				return "", errors.New("string ends with an incomplete escape sequence")
+			}
+			switch e := s[i]; e {
+			case 'n':
+				buf.WriteByte('\n')
+			case 't':
+				buf.WriteByte('\t')
+			case 'u':
+				if i+5 > len(s) {
+					return "", errors.New("invalid unicode escape sequence")
+				}
+				r, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+				if err != nil {
+					return "", errors.New("invalid unicode escape sequence")
+				}
+				buf.WriteRune(rune(r))
+				i += 4
+			case '\'', '"', '\\', '@', '?':
+				buf.WriteByte(e)
+			default:
+				return "", fmt.Errorf("invalid escape sequence \\%c", e)
+			}
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	if quoted {
+		return "", errors.New("string has an unterminated double quote")
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"github.com/weynsee/go-phrase/phrase"
+	"strings"
+	"testing"
+)
+
+func TestAndroidCodec_roundTrip(t *testing.T) {
+	content := `<?xml version="1.0" encoding="UTF-8"?>
+<resources>
+  <!-- greeting -->
+  <string name="hello">Hello <b>%1$s</b>, don\'t</string>
+  <plurals name="apples">
+    <item quantity="one">%d apple</item>
+    <item quantity="other">%d apples</item>
+  </plurals>
+  <string name="app_name" translatable="false">Phrase</string>
+  <string name="html"><![CDATA[<a href="x">Link</a>]]></string>
+  <string-array name="days">
+    <item>Mon</item>
+    <item>Tue</item>
+  </string-array>
+</resources>
+`
+	cat := testRoundTrip(t, &androidCodec{}, content)
+	if len(cat.Messages) != 5 {
+		t.Fatalf("decode expected 5 messages, got %d", len(cat.Messages))
+	}
+	if m := cat.Messages[0]; m.Comment != "greeting" || m.Value != `Hello <b>%1$s</b>, don\'t` {
+		t.Errorf("decode returned unexpected message %+v", m)
+	}
+	if m := cat.Messages[1]; m.Plurals["other"] != "%d apples" {
+		t.Errorf("decode should read plurals, got %+v", m)
+	}
+	if m := cat.Messages[2]; !m.hasFlag(untranslatableFlag) {
+		t.Errorf("decode should flag untranslatable strings, got %+v", m)
+	}
+	if m := cat.Messages[3]; m.Value != `<![CDATA[<a href="x">Link</a>]]>` {
+		t.Errorf("decode should keep CDATA sections, got %+v", m)
+	}
+	if m := cat.Messages[4]; len(m.Array) != 2 || m.Array[1] != "Tue" {
+		t.Errorf("decode should read string arrays, got %+v", m)
+	}
+}
+
+func TestAndroidCodec_otherResources(t *testing.T) {
+	content := `<?xml version="1.0" encoding="utf-8"?>
+<!-- Copyright -->
+<resources xmlns:tools="http://schemas.android.com/tools">
+  <color name="primary">#FF0000</color>
+  <string name="percent" formatted="false" tools:ignore="MissingTranslation">100% of %s</string>
+  <!-- spacing -->
+  <dimen name="margin">8dp</dimen>
+  <integer-array name="sizes">
+    <item>1</item>
+  </integer-array>
+  <string name="hello">Hello</string>
+</resources>
+`
+	cat := testRoundTrip(t, &androidCodec{}, content)
+	if len(cat.Messages) != 5 {
+		t.Fatalf("decode expected 5 messages, got %d", len(cat.Messages))
+	}
+	if m := cat.Messages[0]; m.Key != "" || m.Raw != `<color name="primary">#FF0000</color>` {
+		t.Errorf("decode should keep other resources, got %+v", m)
+	}
+	if m := cat.Messages[1]; m.Key != "percent" || !m.hasFlag(unformattedFlag) {
+		t.Errorf("decode should flag unformatted strings, got %+v", m)
+	}
+	if errs := (&androidCodec{}).validate(cat, new(phrase.Locale)); len(errs) != 0 {
+		t.Errorf("validate should not check the substitutions of unformatted strings, got %v", errs)
+	}
+
+	merged, err := mergeFiles(&androidCodec{}, []byte(content), []byte(`<resources><string name="hello">Hallo</string></resources>`), encodingUTF8)
+	if err != nil {
+		t.Fatalf("mergeFiles returned error %v", err)
+	}
+	if want := strings.Replace(content, ">Hello<", ">Hallo<", 1); string(merged) != want {
+		t.Errorf("mergeFiles should keep other resources, expected %s, got %s", want, merged)
+	}
+}
+
+func TestAndroidCodec_encodeAttributes(t *testing.T) {
+	cat := &catalog{Messages: []*message{
+		{Key: `a"b`, Value: "A", Flags: []string{untranslatableFlag, unformattedFlag}},
+	}}
+	b, err := (&androidCodec{}).encode(cat)
+	if err != nil {
+		t.Fatalf("encode returned error %v", err)
+	}
+	if want := `<string name="a&#34;b" translatable="false" formatted="false">A</string>`; !strings.Contains(string(b), want) {
+		t.Errorf("encode expected %s, got %s", want, b)
+	}
+}
+
+func TestAndroidCodec_error(t *testing.T) {
+	for _, content := range []string{"<strings></strings>", "", "<resources><string>"} {
+		if _, err := (&androidCodec{}).decode([]byte(content)); err == nil {
+			t.Errorf("decode should return an error for %q", content)
+		}
+	}
+}
+
+func TestAndroidUnescape(t *testing.T) {
+	tests := map[string]string{
+		`Don\'t`:                      "Don't",
+		`"Don't"`:                     "Don't",
+		"  a \\n  b  ":                "a \n b",
+		`"  a  "`:                     "  a  ",
+		`\u00e9 \@home \?x \"q\"`:     `é @home ?x "q"`,
+		`<b>bold</b> &amp; <i>it</i>`: "bold & it",
+		`<![CDATA[<b>x</b>]]>`:        "<b>x</b>",
+	}
+	for raw, want := range tests {
+		got, err := androidUnescape(raw)
+		if err != nil {
+			t.Errorf("androidUnescape(%q) returned error %v", raw, err)
+		} else if got != want {
+			t.Errorf("androidUnescape(%q) expected %q, got %q", raw, want, got)
+		}
+	}
+	for _, raw := range []string{`Don't`, `"open`, `a\x`, `\u12`, `trailing\`, `<b>`} {
+		if _, err := androidUnescape(raw); err == nil {
+			t.Errorf("androidUnescape(%q) should return an error", raw)
+		}
+	}
+}
+
+func TestAndroidCodec_validate(t *testing.T) {
+	cat := &catalog{Messages: []*message{
+		{Key: "ok", Value: `%1$s has %2$d new messages, 100%% sure`},
+		{Key: "apostrophe", Value: "Don't"},
+		{Key: "substitutions", Value: "%s has %d"},
+		{Key: "ignored", Value: "Don't", Flags: []string{untranslatableFlag}},
+		{Key: "apples", Plurals: map[string]string{"one": "%d apple", "lots": "%d apples"}},
+		{Key: "days", Array: []string{"Mon", "Tue's"}},
+	}}
+	locale := &phrase.Locale{Pluralizations: map[string]map[string]string{"one": nil, "other": nil}}
+	want := []string{
+		"Key apostrophe: apostrophes must be escaped as \\' or the string must be quoted",
+		"Key substitutions: multiple substitutions must use positional arguments like %1$s",
+		"Key apples has invalid quantity lots",
+		"Key apples is missing the quantities other",
+		"Key days: apostrophes must be escaped as \\' or the string must be quoted",
+	}
+	errs := (&androidCodec{}).validate(cat, locale)
+	if len(errs) != len(want) {
+		t.Fatalf("validate expected %d errors, got %v", len(want), errs)
+	}
+	for i, err := range errs {
+		if err.Error() != want[i] {
+			t.Errorf("validate expected %q, got %q", want[i], err.Error())
+		}
+	}
+}
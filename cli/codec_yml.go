@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"gopkg.in/yaml.v3"
+	"strings"
+)
+
+// ymlCodec reads and writes YAML locale files. Nested mappings are
+// flattened into dot separated keys, and mappings whose keys are all
+// plural categories are read as plural forms. Rooted files, like those used
+// by Rails, nest all keys under the name of the locale.
+type ymlCodec struct {
+	rooted bool
+}
+
+func (c *ymlCodec) decode(b []byte) (*catalog, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	cat := new(catalog)
+	if len(doc.Content) == 0 {
+		return cat, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, errors.New("YAML locale files must contain a mapping")
+	}
+	if c.rooted && len(root.Content) == 2 && root.Content[1].Kind == yaml.MappingNode {
+		cat.Locale = root.Content[0].Value
+		root = root.Content[1]
+	}
+	c.decodeMapping(cat, nil, root)
+	return cat, nil
+}
+
+func (c *ymlCodec) decodeMapping(cat *catalog, prefix []string, node *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if value.Kind == yaml.AliasNode {
+			value = value.Alias
+		}
+		path := append(prefix[:len(prefix):len(prefix)], key.Value)
+		if value.Kind == yaml.MappingNode && !isPluralMapping(value) {
+			c.decodeMapping(cat, path, value)
+			continue
+		}
+		m := &message{Key: strings.Join(path, "."), Path: path, Comment: yamlComment(key.HeadComment)}
+		switch value.Kind {
+		case yaml.MappingNode:
+			m.Plurals = make(map[string]string)
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				m.Plurals[value.Content[j].Value] = value.Content[j+1].Value
+			}
+		case yaml.SequenceNode:
+			m.Array = make([]string, len(value.Content))
+			for j, item := range value.Content {
+				m.Array[j] = item.Value
+			}
+		default:
+			if tag := value.ShortTag(); tag != "!!str" {
+				m.Tag = tag
+			}
+			if m.Tag != "!!null" {
+				m.Value = value.Value
+			}
+		}
+		cat.Messages = append(cat.Messages, m)
+	}
+}
+
+func isPluralMapping(node *yaml.Node) bool {
+	if len(node.Content) == 0 {
+		return false
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if !isPluralCategory(node.Content[i].Value) || node.Content[i+1].Kind != yaml.ScalarNode {
+			return false
+		}
+	}
+	return true
+}
+
+func yamlComment(comment string) string {
+	var lines []string
+	for _, line := range strings.Split(comment, "\n") {
+		lines = append(lines, strings.TrimSpace(strings.TrimPrefix(line, "#")))
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func (c *ymlCodec) encode(cat *catalog) ([]byte, error) {
+	tree, err := nestKeys(cat.Messages)
+	if err != nil {
+		return nil, err
+	}
+	root := c.encodeNode(tree)
+	if c.rooted && cat.Locale != "" {
+		root = &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{yamlString(cat.Locale), root}}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *ymlCodec) encodeNode(tree *keyNode) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, child := range tree.children {
+		key := yamlString(child.name)
+		var value *yaml.Node
+		if m := child.message; m != nil {
+			if m.Comment != "" {
+				key.HeadComment = "# " + strings.Replace(m.Comment, "\n", "\n# ", -1)
+			}
+			switch {
+			case m.Plurals != nil:
+				value = &yaml.Node{Kind: yaml.MappingNode}
+				for _, category := range pluralKeys(m.Plurals) {
+					value.Content = append(value.Content, yamlString(category), yamlString(m.Plurals[category]))
+				}
+			case m.Array != nil:
+				value = &yaml.Node{Kind: yaml.SequenceNode}
+				for _, item := range m.Array {
+					value.Content = append(value.Content, yamlString(item))
+				}
+			default:
+				value = yamlScalar(m)
+			}
+		} else {
+			value = c.encodeNode(child)
+		}
+		node.Content = append(node.Content, key, value)
+	}
+	return node
+}
+
+// yamlScalar returns the node of the value of m, with its type if it
+// still fits it, or as a string.
+func yamlScalar(m *message) *yaml.Node {
+	if m.Tag == "!!null" && m.Value == "" {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	}
+	node := &yaml.Node{Kind: yaml.ScalarNode, Value: m.Value}
+	if m.Tag != "" && node.ShortTag() == m.Tag {
+		return node
+	}
+	return yamlString(m.Value)
+}
+
+func yamlString(s string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s}
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"testing"
+)
+
+func TestYmlCodec_roundTrip(t *testing.T) {
+	content := `en:
+  # greeting
+  hello: Hello
+  app:
+    title: 'Title: the app'
+  apples:
+    one: 1 apple
+    other: '%{count} apples'
+  days:
+    - Mon
+    - Tue
+`
+	cat := testRoundTrip(t, &ymlCodec{rooted: true}, content)
+	if cat.Locale != "en" {
+		t.Errorf("decode expected locale en, got %q", cat.Locale)
+	}
+	if len(cat.Messages) != 4 {
+		t.Fatalf("decode expected 4 messages, got %d", len(cat.Messages))
+	}
+	if m := cat.Messages[0]; m.Key != "hello" || m.Comment != "greeting" {
+		t.Errorf("decode returned unexpected message %+v", m)
+	}
+	if m := cat.Messages[1]; m.Key != "app.title" || m.Value != "Title: the app" {
+		t.Errorf("decode returned unexpected message %+v", m)
+	}
+	if m := cat.Messages[2]; m.Plurals["other"] != "%{count} apples" {
+		t.Errorf("decode should read plurals, got %+v", m)
+	}
+	if m := cat.Messages[3]; len(m.Array) != 2 {
+		t.Errorf("decode should read arrays, got %+v", m)
+	}
+}
+
+func TestYmlCodec_unrooted(t *testing.T) {
+	cat := testRoundTrip(t, &ymlCodec{}, "en:\n  hello: Hello\n")
+	if cat.Locale != "" || cat.Messages[0].Key != "en.hello" {
+		t.Errorf("decode should not strip the root of unrooted files, got %+v", cat.Messages[0])
+	}
+}
+
+func TestYmlCodec_error(t *testing.T) {
+	if _, err := (&ymlCodec{}).decode([]byte("- a\n- b\n")); err == nil {
+		t.Error("decode should return an error for files without a mapping")
+	}
+}
+
+func TestYmlCodec_types(t *testing.T) {
+	content := `en:
+  count: 3
+  ok: true
+  none: null
+  text: "3"
+  a.b: dotted
+`
+	cat := testRoundTrip(t, &ymlCodec{rooted: true}, content)
+	if m := cat.Messages[0]; m.Value != "3" || m.Tag != "!!int" {
+		t.Errorf("decode should keep the type of numbers, got %+v", m)
+	}
+	if m := cat.Messages[4]; m.Key != "a.b" || len(m.Path) != 1 {
+		t.Errorf("decode should keep the segments of dotted keys, got %+v", m)
+	}
+}
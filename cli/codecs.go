@@ -0,0 +1,304 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/weynsee/go-phrase/phrase"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// codec parses the content of a locale file into a catalog and writes
+// a catalog back in the same format. Codecs keep the order of the
+// messages, so that a decoded file can be encoded without noisy diffs.
+type codec interface {
+	decode([]byte) (*catalog, error)
+	encode(*catalog) ([]byte, error)
+}
+
+// localeValidator is implemented by codecs that can check whether the
+// content of a file is consistent with the locale it belongs to, e.g. that
+// it has the number of plural forms the locale uses.
+type localeValidator interface {
+	validate(cat *catalog, locale *phrase.Locale) []error
+}
+
+// catalog is the content of a locale file, independent of its format.
+type catalog struct {
+	// Locale the translations belong to, for formats that store it in the file.
+	Locale string
+	// Locale of the source strings, for bilingual formats like XLIFF.
+	SourceLocale string
+	// Encoding the file was read in, so that it is written back the same way.
+	Encoding textEncoding
+	// Version of the file format, for formats like XLIFF that have several.
+	Version string
+	// Syntax of formats that can be written in several, like go-i18n.
+	Syntax string
+	// File the catalog was extracted from, its type and its identifier in
+	// the document, for formats like XLIFF that record them.
+	Original string
+	DataType string
+	FileID   string
+	// Text written as it is before the messages, like the XML declaration
+	// and the root element of Android resources with its namespaces.
+	Header   string
+	Messages []*message
+	// Lines written as they are after the messages, like the obsolete #~
+	// entries of gettext files, or the comments at the end of .strings files.
+	Trailer []string
+}
+
+// message is a single translation key and its translation.
+type message struct {
+	Key string
+	// Identifier of the message in formats that name it apart from its
+	// key, like XLIFF units with both an id and a resname.
+	ID string
+	// Segments of the key in formats that nest their keys, like YAML, which
+	// may contain dots of their own. Keys are split on dots if it is nil.
+	Path []string
+	// Plural form of the key, e.g. msgid_plural in gettext.
+	KeyPlural string
+	// Context that disambiguates identical keys, e.g. msgctxt in gettext.
+	Context string
+	// Source string of bilingual formats like XLIFF.
+	Source string
+	Value  string
+	// Type of values that are not strings in formats with typed scalars,
+	// like JSON or YAML, as a YAML tag, e.g. !!int or !!null. Values are
+	// written back with their type as long as they still fit it.
+	Tag string
+	// Plural forms of the translation keyed by CLDR plural category
+	// (zero, one, two, few, many, other), or by the index of the form for
+	// formats that number them, like gettext.
+	Plurals map[string]string
+	// Items of array values, e.g. string-array in Android resources.
+	Array []string
+	// Variables of the value that have plural forms of their own, e.g. in
+	// iOS .stringsdict files.
+	Variables []*variable
+	// Comment written by translators or developers.
+	Comment string
+	// Description for translators, e.g. extracted comments in gettext.
+	Description string
+	// References to the source code, e.g. #: comments in gettext.
+	References []string
+	// Flags of the message, e.g. fuzzy in gettext.
+	Flags []string
+	// State of the translation in the file, e.g. the state of XLIFF targets.
+	State string
+	// Whether the translation still needs to be reviewed, like
+	// Translation.Unverified in PhraseApp.
+	Unverified bool
+	// Metadata of the message that is kept as it is, e.g. the @key objects
+	// of ARB files.
+	Metadata *jsonValue
+	// Value of the message that none of the other fields can hold, kept as
+	// it is, e.g. JSON arrays of numbers or objects, and empty objects.
+	Literal *jsonValue
+	// Attributes of the element of the message in XML formats, as they are
+	// written in the file, e.g. tools:ignore in Android resources.
+	Attributes string
+	// Whitespace and comments around the parts of the message as they are
+	// written in the file, for formats whose layout is kept, like .strings.
+	Trivia []string
+	// Lines of the strings of the message that are written over several
+	// lines in the file, keyed by their keyword, e.g. the msgstr of gettext
+	// headers. They are written the same way as long as they still make up
+	// the string.
+	Lines map[string][]string
+	// Content of the file that is not a translation and is written back as
+	// it is, e.g. <color> elements in Android resources. Such messages have
+	// no key.
+	Raw string
+}
+
+// untranslatableFlag marks messages that must not be translated, like
+// Android resources with translatable="false". They are not managed in
+// PhraseApp.
+const untranslatableFlag = "untranslatable"
+
+// variable is a placeholder in a message whose text depends on the number
+// it is replaced with.
+type variable struct {
+	Name string
+	// Type of the number, as a format specifier without the percent sign, e.g. d.
+	ValueType string
+	Plurals   map[string]string
+}
+
+var pluralCategories = []string{"zero", "one", "two", "few", "many", "other"}
+
+func isPluralCategory(s string) bool {
+	for _, category := range pluralCategories {
+		if s == category {
+			return true
+		}
+	}
+	return false
+}
+
+// pluralKeys returns the keys of plurals, with CLDR categories in their
+// canonical order followed by all other keys sorted.
+func pluralKeys(plurals map[string]string) []string {
+	keys := make([]string, 0, len(plurals))
+	for _, category := range pluralCategories {
+		if _, ok := plurals[category]; ok {
+			keys = append(keys, category)
+		}
+	}
+	var others []string
+	for key := range plurals {
+		if !isPluralCategory(key) {
+			others = append(others, key)
+		}
+	}
+	sort.Strings(others)
+	return append(keys, others...)
+}
+
+// hasFlag returns whether the message has the given flag, like fuzzy.
+func (m *message) hasFlag(flag string) bool {
+	for _, f := range m.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// id identifies a message within a catalog.
+func (m *message) id() string {
+	if m.Context != "" {
+		return m.Context + "|" + m.Key
+	}
+	return m.Key
+}
+
+// find returns the message with the given id, or nil.
+func (c *catalog) find(id string) *message {
+	for _, m := range c.Messages {
+		if m.id() == id {
+			return m
+		}
+	}
+	return nil
+}
+
+// flatten returns every translation of the catalog keyed by the message id.
+// Plural forms and array items get the plural category or the index
+// of the item appended to their key.
+func (c *catalog) flatten() map[string]string {
+	keys := make(map[string]string)
+	for _, m := range c.Messages {
+		id := m.id()
+		if id == "" || m.hasFlag(untranslatableFlag) {
+			// headers, like the one in gettext files, and strings that are
+			// not translated
+			continue
+		}
+		switch {
+		case m.Plurals != nil:
+			for category, value := range m.Plurals {
+				keys[joinKey(id, category)] = value
+			}
+		case m.Array != nil:
+			for i, value := range m.Array {
+				keys[joinKey(id, strconv.Itoa(i))] = value
+			}
+		case m.Literal != nil:
+			flattenJSON(keys, id, m.Literal)
+		case m.Variables != nil:
+			keys[id] = m.Value
+			for _, v := range m.Variables {
+				for category, value := range v.Plurals {
+					keys[joinKey(joinKey(id, v.Name), category)] = value
+				}
+			}
+		default:
+			keys[id] = m.Value
+		}
+	}
+	return keys
+}
+
+// flattenJSON adds the scalars of v to keys, keyed like flatten does.
+func flattenJSON(keys map[string]string, id string, v *jsonValue) {
+	switch v.kind {
+	case jsonObject:
+		for i, key := range v.keys {
+			flattenJSON(keys, joinKey(id, key), v.values[i])
+		}
+	case jsonArray:
+		for i, item := range v.items {
+			flattenJSON(keys, joinKey(id, strconv.Itoa(i)), item)
+		}
+	default:
+		keys[id] = v.scalar
+	}
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func splitKey(key string) []string {
+	return strings.Split(key, ".")
+}
+
+// path returns the segments of the key of the message.
+func (m *message) path() []string {
+	if m.Path != nil {
+		return m.Path
+	}
+	return splitKey(m.Key)
+}
+
+// keyNode is a node in the tree of nested keys, used by formats that
+// nest their keys like YAML or nested JSON. Leaf nodes hold a message.
+type keyNode struct {
+	name     string
+	message  *message
+	children []*keyNode
+}
+
+func (n *keyNode) child(name string) *keyNode {
+	for _, child := range n.children {
+		if child.name == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// nestKeys builds the tree of nested keys for the messages, from the
+// segments of their keys. Siblings keep the order of their first message.
+func nestKeys(messages []*message) (*keyNode, error) {
+	root := new(keyNode)
+	for _, m := range messages {
+		node := root
+		for _, part := range m.path() {
+			if node.message != nil {
+				return nil, fmt.Errorf("Key %s conflicts with key %s", m.Key, node.message.Key)
+			}
+			child := node.child(part)
+			if child == nil {
+				child = &keyNode{name: part}
+				node.children = append(node.children, child)
+			}
+			node = child
+		}
+		if node.message != nil {
+			return nil, fmt.Errorf("Key %s is defined more than once", m.Key)
+		}
+		if len(node.children) > 0 {
+			return nil, fmt.Errorf("Key %s conflicts with nested keys", m.Key)
+		}
+		node.message = m
+	}
+	return root, nil
+}
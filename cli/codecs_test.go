@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testRoundTrip decodes content and checks that encoding the catalog
+// gives back the same content.
+func testRoundTrip(t *testing.T, c codec, content string) *catalog {
+	cat, err := c.decode([]byte(content))
+	if err != nil {
+		t.Fatalf("decode returned error %v", err)
+	}
+	b, err := c.encode(cat)
+	if err != nil {
+		t.Fatalf("encode returned error %v", err)
+	}
+	if string(b) != content {
+		t.Errorf("encode expected\n%s\ngot\n%s", content, b)
+	}
+	return cat
+}
+
+func TestCatalog_flatten(t *testing.T) {
+	cat := &catalog{Messages: []*message{
+		{Key: "", Value: "header"},
+		{Key: "hello", Value: "Hello"},
+		{Key: "open", Context: "menu", Value: "Open"},
+		{Key: "apples", Plurals: map[string]string{"one": "1 apple", "other": "%d apples"}},
+		{Key: "days", Array: []string{"Mon", "Tue"}},
+	}}
+	want := map[string]string{
+		"hello":        "Hello",
+		"menu|open":    "Open",
+		"apples.one":   "1 apple",
+		"apples.other": "%d apples",
+		"days.0":       "Mon",
+		"days.1":       "Tue",
+	}
+	if got := cat.flatten(); !reflect.DeepEqual(got, want) {
+		t.Errorf("flatten expected %v, got %v", want, got)
+	}
+}
+
+func TestPluralKeys(t *testing.T) {
+	got := pluralKeys(map[string]string{"other": "", "1": "", "one": "", "0": "", "few": ""})
+	want := []string{"one", "few", "other", "0", "1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("pluralKeys expected %v, got %v", want, got)
+	}
+}
+
+func TestNestKeys(t *testing.T) {
+	root, err := nestKeys([]*message{{Key: "a.b"}, {Key: "c"}, {Key: "a.d"}})
+	if err != nil {
+		t.Fatalf("nestKeys returned error %v", err)
+	}
+	if len(root.children) != 2 || root.children[0].name != "a" || root.children[1].name != "c" {
+		t.Fatalf("nestKeys should keep the order of the keys, got %+v", root.children)
+	}
+	if a := root.children[0]; len(a.children) != 2 || a.children[1].message.Key != "a.d" {
+		t.Errorf("nestKeys should nest keys, got %+v", a.children)
+	}
+}
+
+func TestNestKeys_conflict(t *testing.T) {
+	for _, keys := range [][]string{{"a", "a.b"}, {"a.b", "a"}, {"a", "a"}} {
+		messages := []*message{{Key: keys[0]}, {Key: keys[1]}}
+		if _, err := nestKeys(messages); err == nil {
+			t.Errorf("nestKeys should return an error for keys %v", keys)
+		}
+	}
+}
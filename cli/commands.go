@@ -1,14 +1,36 @@
 package cli
 
 import (
+	"fmt"
 	mcli "github.com/mitchellh/cli"
 	"github.com/weynsee/go-phrase/phrase"
 	"os"
+	"path/filepath"
 )
 
 var commands map[string]mcli.CommandFactory
 
 func init() {
+	path, root := defaultConfig()
+	commands = newCommands(path, root, os.Getenv("PHRASE_PROFILE"))
+}
+
+// defaultConfig returns the path of the config file found from the current
+// directory, and the directory paths in it are relative to.
+func defaultConfig() (path, root string) {
+	if path = findConfig("."); path == "" {
+		return ".phrase", ""
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		root = dir
+	}
+	return path, root
+}
+
+// newCommands returns the commands, with the config file at path and the
+// named profile of it. Relative paths in the config are relative to root,
+// or the current directory if root is empty.
+func newCommands(path, root, profile string) map[string]mcli.CommandFactory {
 	ui := &mcli.ConcurrentUi{
 		Ui: &mcli.ColoredUi{
 			Ui: &mcli.BasicUi{
@@ -22,10 +44,40 @@ func init() {
 		},
 	}
 
-	config, _ := NewConfig(".phrase")
+	config, configErr := newConfig(path, profile)
+	if configErr != nil {
+		configErr = fmt.Errorf("Error reading %s:\n\t%s", path, configErr.Error())
+		config = &Config{path: path}
+		config.setDefaults()
+	}
+	config.root = root
 	api := phrase.New(config.Secret)
 
-	commands = map[string]mcli.CommandFactory{
+	// commands cannot run with an invalid config, except for the command
+	// that reports its problems and the one that writes a new config
+	withConfig := func(factory mcli.CommandFactory) mcli.CommandFactory {
+		return func() (mcli.Command, error) {
+			if configErr != nil {
+				return nil, configErr
+			}
+			return factory()
+		}
+	}
+
+	commands := map[string]mcli.CommandFactory{
+		"blacklist": func() (mcli.Command, error) {
+			return &BlacklistCommand{
+				UI:     ui,
+				Config: config,
+				API:    api,
+			}, nil
+		},
+		"config": func() (mcli.Command, error) {
+			return &ConfigCommand{
+				UI:     ui,
+				Config: config,
+			}, nil
+		},
 		"init": func() (mcli.Command, error) {
 			return &InitCommand{
 				UI:     ui,
@@ -33,6 +85,27 @@ func init() {
 				API:    api,
 			}, nil
 		},
+		"lint": func() (mcli.Command, error) {
+			return &LintCommand{
+				UI:     ui,
+				Config: config,
+				API:    api,
+			}, nil
+		},
+		"login": func() (mcli.Command, error) {
+			return &LoginCommand{
+				UI:     ui,
+				Config: config,
+				API:    api,
+			}, nil
+		},
+		"logout": func() (mcli.Command, error) {
+			return &LogoutCommand{
+				UI:     ui,
+				Config: config,
+				API:    api,
+			}, nil
+		},
 		"push": func() (mcli.Command, error) {
 			return &PushCommand{
 				UI:     ui,
@@ -47,6 +120,13 @@ func init() {
 				API:    api,
 			}, nil
 		},
+		"status": func() (mcli.Command, error) {
+			return &StatusCommand{
+				UI:     ui,
+				Config: config,
+				API:    api,
+			}, nil
+		},
 		"tags": func() (mcli.Command, error) {
 			return &TagsCommand{
 				UI:     ui,
@@ -54,5 +134,24 @@ func init() {
 				API:    api,
 			}, nil
 		},
+		"whoami": func() (mcli.Command, error) {
+			return &WhoamiCommand{
+				UI:     ui,
+				Config: config,
+				API:    api,
+			}, nil
+		},
+		"xliff": func() (mcli.Command, error) {
+			return &XliffCommand{
+				UI:     ui,
+				Config: config,
+			}, nil
+		},
+	}
+	for name, factory := range commands {
+		if name != "config" && name != "init" {
+			commands[name] = withConfig(factory)
+		}
 	}
+	return commands
 }
@@ -1,11 +1,13 @@
 package cli
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 )
 
 func TestCommands(t *testing.T) {
-	keys := []string{"push", "pull", "tags", "init"}
+	keys := []string{"push", "pull", "status", "tags", "init", "xliff", "config", "login", "logout", "whoami", "blacklist", "lint"}
 	for _, command := range keys {
 		_, err := commands[command]()
 		if err != nil {
@@ -13,3 +15,19 @@ func TestCommands(t *testing.T) {
 		}
 	}
 }
+
+func TestCommands_invalidConfig(t *testing.T) {
+	path := filepath.Join(testFolder, ".phrase")
+	createTestFiles(map[string][]byte{".phrase": []byte(`{"format": 1}`)})
+	defer os.RemoveAll(testFolder)
+
+	commands := newCommands(path, "", "")
+	for _, command := range []string{"config", "init"} {
+		if _, err := commands[command](); err != nil {
+			t.Errorf("%s command should run with an invalid config, got %s", command, err.Error())
+		}
+	}
+	if _, err := commands["push"](); err == nil {
+		t.Error("push command should not run with an invalid config")
+	}
+}
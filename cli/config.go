@@ -3,16 +3,36 @@ package cli
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/weynsee/go-phrase/phrase"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 )
 
 // Config stores the default values for some of the properties of the PhraseApp API client
 type Config struct {
 	path string
+	// root is the directory paths in the config are relative to, when it
+	// is not the current directory.
+	root string
+	// profile is the name of the profile in use, and profiles the content
+	// of all the profiles of the file.
+	profile  string
+	profiles map[string]json.RawMessage
+	// file and loaded are the config as it is in the file and as it was
+	// loaded, with the profile and environment variables, so that only
+	// changes are saved.
+	file, loaded *Config
 
-	// Project auth token. You can find the auth token in your project overview or project settings form.
-	Secret string `json:"secret"`
+	// Project auth token. You can find the auth token in your project overview or project settings form. Leave it out of config files that are committed: init stores it in the credentials of the user instead.
+	Secret string `json:"secret,omitempty"`
+	// Name of the project, under which its auth token is stored in the credentials of the user when the config has no secret.
+	Project string `json:"project,omitempty"`
 	// Default locale  your PhraseApp project (default is en).
 	DefaultLocale string `json:"default_locale"`
 	// Set a domain for use with Gettext translation files (default is phrase).
@@ -27,6 +47,18 @@ type Config struct {
 	LocaleFilename string `json:"locale_filename,omitempty"`
 	// Set the encoding for your localization files to UTF-8, UTF-16 or Latin-1. Please note that the encodings only work for a handful of formats like IOS .strings or Java .properties. The default will be UTF-8. If none is provided the default encoding of the formats is used.
 	Encoding string `json:"encoding,omitempty"`
+	// Set the encoding for the localization files of specific formats, e.g. {"strings": "UTF-16"}. Overrides the encoding for those formats.
+	Encodings map[string]string `json:"encodings,omitempty"`
+	// Set the maximum number of files that are uploaded or downloaded at the same time (default is 2).
+	Concurrency int `json:"concurrency,omitempty"`
+	// Compile pulled gettext .po files into binary .mo files next to them.
+	CompileMO bool `json:"compile_mo,omitempty"`
+	// Sets of locale files uploaded by push, e.g. for the apps of a monorepo. Push uploads all of them when no file or directory is given.
+	Sources []*SourceConfig `json:"sources,omitempty"`
+	// Sets of locale files downloaded by pull, each with its own format and paths. Pull downloads all of them unless --format or --target are given.
+	Targets []*TargetConfig `json:"targets,omitempty"`
+	// Named sets of settings that override the others when selected with --profile or PHRASE_PROFILE, e.g. for the staging and production projects.
+	Profiles map[string]*Config `json:"profiles,omitempty"`
 }
 
 // LocaleConfig stores locale specific configuration options
@@ -36,32 +68,184 @@ type LocaleConfig struct {
 }
 
 // NewConfig returns a Config instance. Its properties will be
-// populated from the file found in the path argument
-// (assumed to be in a certain JSON format), and some properties
-// will have default values assigned.
+// populated from the file found in the path argument, in YAML if its
+// extension is .yml or .yaml and in JSON otherwise, then overridden by the
+// profile named in PHRASE_PROFILE and by PHRASE_* environment variables,
+// and some properties will have default values assigned. Files with
+// unknown keys or values of the wrong type return an error. Command line
+// flags override all of these. The secret of the project is only read from
+// the credentials of the user when a command needs it, see loadSecret.
 func NewConfig(path string) (*Config, error) {
+	return newConfig(path, os.Getenv("PHRASE_PROFILE"))
+}
+
+// newConfig returns the config of the file at path, with the named
+// profile, if any.
+func newConfig(path, profile string) (*Config, error) {
 	config := new(Config)
 	config.path = path
-	if _, err := os.Stat(path); err == nil {
+	config.file = new(Config)
+	if b, err := ioutil.ReadFile(path); err == nil {
 		// if file exists, initialize the object with its contents
-		f, err := os.Open(path)
-		if err != nil {
+		if err = config.decode(b); err != nil {
 			return nil, err
 		}
-		if err = json.NewDecoder(f).Decode(config); err != nil {
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	if profile != "" {
+		if err := config.applyProfile(profile); err != nil {
 			return nil, err
 		}
 	}
+	if err := config.applyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	config.setDefaults()
+	config.file.setDefaults()
+	loaded := *config
+	config.loaded = &loaded
 
-	// defaults
-	if config.Domain == "" {
-		config.Domain = "phrase"
+	return config, nil
+}
+
+func (c *Config) setDefaults() {
+	if c.Domain == "" {
+		c.Domain = "phrase"
 	}
-	if config.DefaultLocale == "" {
-		config.DefaultLocale = "en"
+	if c.DefaultLocale == "" {
+		c.DefaultLocale = "en"
 	}
+}
 
-	return config, nil
+// decode populates the config from the content of its file.
+func (c *Config) decode(b []byte) error {
+	node, err := parseConfigNode(b, c.path)
+	if err != nil {
+		return err
+	}
+	if errs := checkConfig(node, "", false); len(errs) > 0 {
+		return configErrors(errs)
+	}
+	// the checked content is decoded like JSON, so that both syntaxes
+	// share the keys of the struct tags
+	var raw interface{}
+	if err = node.Decode(&raw); err != nil {
+		return err
+	}
+	if b, err = json.Marshal(raw); err != nil {
+		return err
+	}
+	var content struct {
+		Profiles map[string]json.RawMessage `json:"profiles"`
+	}
+	if err = json.Unmarshal(b, &content); err != nil {
+		return err
+	}
+	for name, profile := range content.Profiles {
+		var keys map[string]json.RawMessage
+		if err = json.Unmarshal(profile, &keys); err != nil {
+			return err
+		}
+		if _, ok := keys["profiles"]; ok {
+			return fmt.Errorf("Profile %s cannot have profiles", name)
+		}
+	}
+	c.profiles = content.Profiles
+	// the file is decoded twice so that the config does not share maps
+	// and lists with the content of the file
+	if err = json.Unmarshal(b, c.file); err != nil {
+		return err
+	}
+	return json.Unmarshal(b, c)
+}
+
+// applyProfile overrides the settings the named profile sets.
+func (c *Config) applyProfile(name string) error {
+	raw, ok := c.profiles[name]
+	if !ok {
+		return fmt.Errorf("Unknown profile %s", name)
+	}
+	profile := new(Config)
+	if err := json.Unmarshal(raw, profile); err != nil {
+		return err
+	}
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &keys); err != nil {
+		return err
+	}
+	v, p := reflect.ValueOf(c).Elem(), reflect.ValueOf(profile).Elem()
+	for i, name := range configFieldNames(v.Type()) {
+		if _, ok := keys[name]; ok {
+			v.Field(i).Set(p.Field(i))
+		}
+	}
+	c.profile = name
+	return nil
+}
+
+// configFieldNames returns the names of the fields of a config struct in
+// config files, keyed by their index.
+func configFieldNames(t reflect.Type) map[int]string {
+	names := make(map[int]string)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if field.PkgPath != "" || name == "" || name == "-" {
+			continue
+		}
+		names[i] = name
+	}
+	return names
+}
+
+// applyEnv overrides every field with the environment variable named after
+// its key, e.g. PHRASE_SECRET for secret or PHRASE_DEFAULT_LOCALE for
+// default_locale. Lists and maps are given in JSON.
+func (c *Config) applyEnv(lookup func(string) (string, bool)) error {
+	v := reflect.ValueOf(c).Elem()
+	for i, name := range configFieldNames(v.Type()) {
+		if name == "profiles" {
+			continue
+		}
+		key := "PHRASE_" + strings.ToUpper(name)
+		value, ok := lookup(key)
+		if !ok {
+			continue
+		}
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(value)
+		case reflect.Int:
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("%s must be an integer", key)
+			}
+			field.SetInt(int64(n))
+		case reflect.Bool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("%s must be true or false", key)
+			}
+			field.SetBool(b)
+		default:
+			field.Set(reflect.Zero(field.Type()))
+			if err := json.Unmarshal([]byte(value), field.Addr().Interface()); err != nil {
+				return fmt.Errorf("%s must be JSON:\n\t%s", key, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// resolve returns path relative to the root of the config, so that a
+// config found in a parent directory works from anywhere below it.
+func (c *Config) resolve(path string) string {
+	if c.root == "" || path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(c.root, path)
 }
 
 // ForLocale returns a LocaleConfig for the given Locale.
@@ -75,9 +259,71 @@ func (c *Config) Valid() error {
 	if !found {
 		return errors.New("Unrecognized format: " + c.Format)
 	}
+	if _, err := parseEncoding(c.Encoding); err != nil {
+		return err
+	}
+	for _, encoding := range c.Encodings {
+		if _, err := parseEncoding(encoding); err != nil {
+			return err
+		}
+	}
+	for _, source := range c.Sources {
+		if err := source.Valid(); err != nil {
+			return err
+		}
+	}
+	for _, target := range c.Targets {
+		if err := target.Valid(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// encodingForFormat returns the encoding configured for the files of a
+// format, or an empty string if there is none.
+func (c *Config) encodingForFormat(format string) string {
+	if encoding, ok := c.Encodings[format]; ok {
+		return encoding
+	}
+	return c.Encoding
+}
+
+// normalizeEncoding converts the content of a file of the given format
+// to the encoding configured for the format. Content is left alone when
+// no encoding is configured.
+func (c *Config) normalizeEncoding(format string, b []byte) ([]byte, error) {
+	name := c.encodingForFormat(format)
+	if name == "" {
+		return b, nil
+	}
+	enc, err := parseEncoding(name)
+	if err != nil {
+		return nil, err
+	}
+	return transcode(b, enc)
+}
+
+// localeFromPath returns the locale of a file from the configured
+// locale_directory and locale_filename, with the patterns of the format for
+// the one that is not configured. It returns "" if neither is configured or
+// path does not match them, and true if the locale is a code.
+func (c *Config) localeFromPath(path, format string) (string, bool) {
+	if c.LocaleDirectory == "" && c.LocaleFilename == "" {
+		return "", false
+	}
+	directory, filename := c.LocaleDirectory, c.LocaleFilename
+	if f, ok := formats[format]; ok {
+		if directory == "" {
+			directory = f.properties().directoryFormat
+		}
+		if filename == "" {
+			filename = f.properties().filenameFormat
+		}
+	}
+	return localeFromPlaceholders(matchPlaceholders(directory, filename, path, c))
+}
+
 func newLocaleConfig(c *Config, l *phrase.Locale) *LocaleConfig {
 	format := formats[c.Format]
 	lc := &LocaleConfig{*c, format}
@@ -93,20 +339,33 @@ func newLocaleConfig(c *Config, l *phrase.Locale) *LocaleConfig {
 			lc.TargetDirectory = "phrase/locales/"
 		}
 	}
+	lc.TargetDirectory = c.resolve(lc.TargetDirectory)
 	return lc
 }
 
 // Save saves current values of the properties of the Config
-// instance to disk.
+// instance to disk. Only the values that changed since the config was
+// loaded are saved, so that the values of the profile and of environment
+// variables stay out of the file. Changes are saved to the profile in use,
+// if any.
 func (c *Config) Save() error {
+	saved, err := c.saved()
+	if err != nil {
+		return err
+	}
 	f, err := os.OpenFile(c.path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0660)
 	if err != nil {
 		return err
 	}
-	bytes, err := json.MarshalIndent(c, "", "  ")
+	bytes, err := json.MarshalIndent(saved, "", "  ")
 	if err != nil {
 		return err
 	}
+	if isYAMLConfig(c.path) {
+		if bytes, err = jsonToYAML(bytes); err != nil {
+			return err
+		}
+	}
 
 	_, err = f.Write(bytes)
 	if err != nil {
@@ -115,3 +374,67 @@ func (c *Config) Save() error {
 
 	return f.Close()
 }
+
+// savedConfig is the content of a saved config file. Profiles are kept as
+// they are in the file, rather than with all the keys of a config.
+type savedConfig struct {
+	*Config
+	Profiles map[string]json.RawMessage `json:"profiles,omitempty"`
+}
+
+// saved returns the content to save to the file of the config.
+func (c *Config) saved() (*savedConfig, error) {
+	if c.file == nil || c.loaded == nil {
+		return &savedConfig{Config: c}, nil
+	}
+	file := *c.file
+	v, f, loaded := reflect.ValueOf(c).Elem(), reflect.ValueOf(&file).Elem(), reflect.ValueOf(c.loaded).Elem()
+	changes := make(map[string]interface{})
+	for i, name := range configFieldNames(v.Type()) {
+		if name == "profiles" || reflect.DeepEqual(v.Field(i).Interface(), loaded.Field(i).Interface()) {
+			continue
+		}
+		if c.profile != "" {
+			changes[name] = v.Field(i).Interface()
+		} else {
+			f.Field(i).Set(v.Field(i))
+		}
+	}
+	profiles := make(map[string]json.RawMessage)
+	for name, profile := range c.profiles {
+		profiles[name] = profile
+	}
+	if len(changes) > 0 {
+		profile := make(map[string]interface{})
+		if err := json.Unmarshal(c.profiles[c.profile], &profile); err != nil {
+			return nil, err
+		}
+		for name, value := range changes {
+			profile[name] = value
+		}
+		b, err := json.Marshal(profile)
+		if err != nil {
+			return nil, err
+		}
+		profiles[c.profile] = b
+	}
+	return &savedConfig{Config: &file, Profiles: profiles}, nil
+}
+
+// jsonToYAML converts JSON to YAML in block style, keeping the order of the
+// keys.
+func jsonToYAML(b []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	var unstyle func(*yaml.Node)
+	unstyle = func(n *yaml.Node) {
+		n.Style = 0
+		for _, child := range n.Content {
+			unstyle(child)
+		}
+	}
+	unstyle(&doc)
+	return yaml.Marshal(&doc)
+}
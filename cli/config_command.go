@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"fmt"
+	mcli "github.com/mitchellh/cli"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// ConfigCommand will check the config file, e.g. in pre-commit hooks.
+type ConfigCommand struct {
+	UI     mcli.Ui
+	Config *Config
+}
+
+// Run executes the config command.
+func (c *ConfigCommand) Run(args []string) int {
+	if len(args) == 0 || args[0] != "validate" || len(args) > 2 {
+		c.UI.Output(c.Help())
+		return 1
+	}
+	path := c.Config.path
+	if len(args) == 2 {
+		path = args[1]
+	}
+	return c.validate(path)
+}
+
+// validate reports all the problems of the config file at path.
+func (c *ConfigCommand) validate(path string) int {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error reading %s:\n\t%s", path, err.Error()))
+		return 1
+	}
+	node, err := parseConfigNode(b, path)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error parsing %s:\n\t%s", path, err.Error()))
+		return 1
+	}
+	errs := checkConfig(node, filepath.Dir(path), true)
+	for _, err := range errs {
+		if e, ok := err.(*configError); ok {
+			c.UI.Error(fmt.Sprintf("%s:%d: %s", path, e.Line, e.Msg))
+		} else {
+			c.UI.Error(fmt.Sprintf("%s: %s", path, err.Error()))
+		}
+	}
+	if len(errs) > 0 {
+		c.UI.Error(fmt.Sprintf("Found %d problem(s) in %s", len(errs), path))
+		return 1
+	}
+	c.UI.Output(fmt.Sprintf("%s is valid", path))
+	return 0
+}
+
+// Help displays available options for the config command.
+func (c *ConfigCommand) Help() string {
+	helpText := `
+	Usage: phrase config validate [FILE]
+
+	  Check the config file for unknown keys, values of the wrong type, unknown formats,
+	  encodings and placeholders, and source and locale directories that do not exist,
+	  relative to the directory of the config file.
+	  Checks .phrase.yml, .phrase.yaml or .phrase unless a file is given.
+	`
+	return strings.TrimSpace(helpText)
+}
+
+// Synopsis displays a synopsis of the config command.
+func (c *ConfigCommand) Synopsis() string {
+	return "Validate the configuration file"
+}
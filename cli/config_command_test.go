@@ -0,0 +1,63 @@
+package cli
+
+import (
+	mcli "github.com/mitchellh/cli"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigCommand_Help(t *testing.T) {
+	c := &ConfigCommand{}
+	if c.Help() == "" {
+		t.Error("Help should return a non-empty string")
+	}
+}
+
+func TestConfigCommand_Synopsis(t *testing.T) {
+	c := &ConfigCommand{}
+	if c.Synopsis() == "" {
+		t.Error("Synopsis should return a non-empty string")
+	}
+}
+
+func TestConfigCommand_validate(t *testing.T) {
+	defer os.RemoveAll(testFolder)
+	prepareLocaleFiles(map[string][]byte{".phrase.yml": []byte("secret: token\nformat: yml\n")}, testFolder)
+
+	ui := new(mcli.MockUi)
+	c := &ConfigCommand{UI: ui, Config: &Config{path: filepath.Join(testFolder, ".phrase.yml")}}
+	if code := c.Run([]string{"validate"}); code != 0 {
+		t.Fatalf("Config command should return code == 0, got %s", ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "is valid") {
+		t.Errorf("Config command should report a valid config, got %q", out)
+	}
+}
+
+func TestConfigCommand_validateErrors(t *testing.T) {
+	defer os.RemoveAll(testFolder)
+	prepareLocaleFiles(map[string][]byte{"phrase.json": []byte("{\n  \"secret\": \"token\",\n  \"format\": \"ymlx\",\n  \"colour\": true\n}\n")}, testFolder)
+	path := filepath.Join(testFolder, "phrase.json")
+
+	ui := new(mcli.MockUi)
+	c := &ConfigCommand{UI: ui, Config: new(Config)}
+	if code := c.Run([]string{"validate", path}); code == 0 {
+		t.Fatal("Config command should return code != 0")
+	}
+	errs := ui.ErrorWriter.String()
+	for _, want := range []string{path + ":3: Unrecognized format: ymlx", path + ":4: Unknown key colour", "Found 2 problem(s)"} {
+		if !strings.Contains(errs, want) {
+			t.Errorf("Config command should report %q, got %q", want, errs)
+		}
+	}
+}
+
+func TestConfigCommand_usage(t *testing.T) {
+	ui := new(mcli.MockUi)
+	c := &ConfigCommand{UI: ui, Config: new(Config)}
+	if code := c.Run([]string{"check"}); code == 0 {
+		t.Error("Config command should return code != 0 for unknown subcommands")
+	}
+}
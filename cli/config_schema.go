@@ -0,0 +1,320 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// configFileNames are the names of the config file, in order of preference.
+var configFileNames = []string{".phrase.yml", ".phrase.yaml", ".phrase"}
+
+// findConfig looks for a config file in dir and its parents, up to the
+// root of the repository dir is in, i.e. the first directory with a .git
+// folder. It returns the path of the file relative to dir, or "" if there
+// is none.
+func findConfig(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for current := dir; ; current = filepath.Dir(current) {
+		for _, name := range configFileNames {
+			path := filepath.Join(current, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				if rel, err := filepath.Rel(dir, path); err == nil {
+					return rel
+				}
+				return path
+			}
+		}
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil || filepath.Dir(current) == current {
+			return ""
+		}
+	}
+}
+
+// isYAMLConfig returns whether the config file at path is written in YAML.
+// All other config files are JSON.
+func isYAMLConfig(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yml" || ext == ".yaml"
+}
+
+// configError is a problem at a line of a config file.
+type configError struct {
+	Line int
+	Msg  string
+}
+
+func (e *configError) Error() string {
+	return fmt.Sprintf("Line %d: %s", e.Line, e.Msg)
+}
+
+// configErrors are all the problems of a config file.
+type configErrors []error
+
+func (e configErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n\t")
+}
+
+// parseConfigNode parses the content of a config file. JSON is read as
+// YAML, of which it is a subset, so that problems of both can be reported
+// with their line.
+func parseConfigNode(b []byte, path string) (*yaml.Node, error) {
+	if !isYAMLConfig(path) {
+		// tabs can only be whitespace in JSON, but cannot indent YAML
+		b = bytes.Replace(b, []byte("\t"), []byte(" "), -1)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: 1}, nil
+	}
+	return doc.Content[0], nil
+}
+
+var configType = reflect.TypeOf(Config{})
+
+// configRequired lists the keys that must be set in the entries of lists.
+var configRequired = map[reflect.Type][]string{
+	reflect.TypeOf(SourceConfig{}): {"files"},
+	reflect.TypeOf(TargetConfig{}): {"format"},
+}
+
+// configChecks check the values of the keys of config files, beyond their
+// type. They are only run to validate config files, so that commands do not
+// fail on problems that do not concern them.
+var configChecks = map[string]func(string) error{
+	"format":           checkFormat,
+	"encoding":         checkEncoding,
+	"encodings":        checkEncoding,
+	"locale_directory": checkPlaceholders,
+	"locale_filename":  checkPlaceholders,
+	"target_directory": checkPlaceholders,
+	"file":             checkPlaceholders,
+	"tags":             checkTag,
+}
+
+// configPathChecks check the values of the keys that are paths, which are
+// relative to the directory of the config file.
+var configPathChecks = map[string]func(root, value string) error{
+	"files": checkSourceFiles,
+}
+
+// configKeyChecks check the keys of maps, like the formats of encodings.
+var configKeyChecks = map[string]func(string) error{
+	"encodings": checkFormat,
+}
+
+// checkConfig returns the problems of the parsed content of a config file:
+// unknown keys, values of the wrong type and missing required keys, and
+// invalid values if checkValues is set. Paths are checked relative to root,
+// the directory of the config file.
+func checkConfig(node *yaml.Node, root string, checkValues bool) []error {
+	return checkConfigNode(node, configType, "config", root, checkValues)
+}
+
+func checkConfigNode(node *yaml.Node, t reflect.Type, key, root string, checkValues bool) []error {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Tag == "!!null" {
+		return nil
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var errs []error
+	fail := func(n *yaml.Node, format string, args ...interface{}) {
+		errs = append(errs, &configError{Line: n.Line, Msg: fmt.Sprintf(format, args...)})
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			fail(node, "%s must be a mapping", key)
+			break
+		}
+		fields := configFields(t)
+		seen := make(map[string]bool)
+		values := make(map[string]*yaml.Node)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k, v := node.Content[i], node.Content[i+1]
+			field, ok := fields[k.Value]
+			switch {
+			case !ok:
+				fail(k, "Unknown key %s", k.Value)
+			case seen[k.Value]:
+				fail(k, "Key %s is defined more than once", k.Value)
+			default:
+				errs = append(errs, checkConfigNode(v, field, k.Value, root, checkValues)...)
+			}
+			seen[k.Value] = true
+			values[k.Value] = v
+		}
+		for _, required := range configRequired[t] {
+			if !seen[required] {
+				fail(node, "Key %s is required", required)
+			}
+		}
+		if t == configType && checkValues {
+			errs = append(errs, checkLocaleDirectory(root, values["format"], values["target_directory"], values["locale_directory"])...)
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			fail(node, "%s must be a list", key)
+			break
+		}
+		for _, item := range node.Content {
+			errs = append(errs, checkConfigNode(item, t.Elem(), key, root, checkValues)...)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			fail(node, "%s must be a mapping", key)
+			break
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k, v := node.Content[i], node.Content[i+1]
+			if check, ok := configKeyChecks[key]; ok && checkValues {
+				if err := check(k.Value); err != nil {
+					fail(k, "%s", err.Error())
+				}
+			}
+			errs = append(errs, checkConfigNode(v, t.Elem(), key, root, checkValues)...)
+		}
+	case reflect.String:
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!str" {
+			fail(node, "%s must be a string", key)
+			break
+		}
+		if check, ok := configChecks[key]; ok && checkValues {
+			if err := check(node.Value); err != nil {
+				fail(node, "%s", err.Error())
+			}
+		}
+		if check, ok := configPathChecks[key]; ok && checkValues {
+			if err := check(root, node.Value); err != nil {
+				fail(node, "%s", err.Error())
+			}
+		}
+	case reflect.Int:
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!int" {
+			fail(node, "%s must be an integer", key)
+		}
+	case reflect.Bool:
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!bool" {
+			fail(node, "%s must be true or false", key)
+		}
+	}
+	return errs
+}
+
+// configFields returns the types of the fields of a config struct, keyed
+// by their name in config files.
+func configFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i, name := range configFieldNames(t) {
+		fields[name] = t.Field(i).Type
+	}
+	return fields
+}
+
+func checkFormat(format string) error {
+	if _, found := formats[format]; format != "" && !found {
+		return errors.New("Unrecognized format: " + format)
+	}
+	return nil
+}
+
+func checkEncoding(encoding string) error {
+	_, err := parseEncoding(encoding)
+	return err
+}
+
+func checkTag(tag string) error {
+	if !validTag.MatchString(tag) {
+		return fmt.Errorf("Tag %s is invalid: Only letters, numbers, underscores and dashes are allowed", tag)
+	}
+	return nil
+}
+
+var anyPlaceholder = regexp.MustCompile(`<[^<>/]*>`)
+
+func checkPlaceholders(s string) error {
+	for _, placeholder := range anyPlaceholder.FindAllString(s, -1) {
+		if !placeholderFormat.MatchString(placeholder) {
+			return fmt.Errorf("Unknown placeholder %s, expected <locale.name>, <locale.code>, <locale>, <domain> or <format>", placeholder)
+		}
+	}
+	return nil
+}
+
+// checkSourceFiles checks the pattern of the files of a source, and that
+// the directory it starts from exists.
+func checkSourceFiles(root, pattern string) error {
+	if err := checkPlaceholders(pattern); err != nil {
+		return err
+	}
+	s := &SourceConfig{Files: pattern}
+	if _, err := filepath.Match(s.glob(), ""); err != nil {
+		return fmt.Errorf("Invalid files pattern %s: %s", pattern, err.Error())
+	}
+	return checkDirectory(root, s.glob())
+}
+
+// checkLocaleDirectory checks that the directory of the locale files exists,
+// up to the first placeholder of the target and locale directories. The
+// nodes are nil for the keys that are not set, whose defaults are used
+// like pull does.
+func checkLocaleDirectory(root string, format, target, locale *yaml.Node) []error {
+	if target == nil && locale == nil {
+		return nil
+	}
+	dir, node := "phrase/locales/", target
+	if target != nil {
+		dir = target.Value
+	} else if format != nil {
+		if f, ok := formats[format.Value]; ok && f.properties().targetDirectory != "" {
+			dir = f.properties().targetDirectory
+		}
+	}
+	if locale != nil {
+		dir, node = filepath.Join(dir, locale.Value), locale
+	}
+	if checkPlaceholders(dir) != nil {
+		return nil
+	}
+	if err := checkDirectory(root, placeholderFormat.ReplaceAllString(dir, "*")+string(filepath.Separator)); err != nil {
+		return []error{&configError{Line: node.Line, Msg: err.Error()}}
+	}
+	return nil
+}
+
+// checkDirectory checks that the directory a pattern starts from exists,
+// relative to root unless it is absolute.
+func checkDirectory(root, pattern string) error {
+	dir := filepath.Dir(pattern)
+	for strings.ContainsAny(dir, "*?[") {
+		dir = filepath.Dir(dir)
+	}
+	path := dir
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("Directory %s does not exist", dir)
+	}
+	return nil
+}
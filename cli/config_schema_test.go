@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testCheckConfig(t *testing.T, path, content string, checkValues bool) []error {
+	node, err := parseConfigNode([]byte(content), path)
+	if err != nil {
+		t.Fatalf("parseConfigNode returned error %v", err)
+	}
+	return checkConfig(node, "", checkValues)
+}
+
+func TestCheckConfig_schema(t *testing.T) {
+	content := `secret: token
+secrte: typo
+concurrency: two
+compile_mo: yes please
+sources:
+  - format: xml
+  - files: "*.json"
+    tags: web
+targets:
+  format: xml
+`
+	errs := testCheckConfig(t, ".phrase.yml", content, false)
+	want := []string{
+		"Line 2: Unknown key secrte",
+		"Line 3: concurrency must be an integer",
+		"Line 4: compile_mo must be true or false",
+		"Line 6: Key files is required",
+		"Line 8: tags must be a list",
+		"Line 10: targets must be a list",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("checkConfig expected %d errors, got %v", len(want), errs)
+	}
+	for i, err := range errs {
+		if err.Error() != want[i] {
+			t.Errorf("checkConfig expected %q, got %q", want[i], err.Error())
+		}
+	}
+}
+
+func TestCheckConfig_json(t *testing.T) {
+	content := "{\n\t\"secret\": \"token\",\n\t\"formt\": \"yml\"\n}"
+	errs := testCheckConfig(t, ".phrase", content, false)
+	if len(errs) != 1 || errs[0].Error() != "Line 3: Unknown key formt" {
+		t.Errorf("checkConfig should report unknown keys of JSON files with their line, got %v", errs)
+	}
+}
+
+func TestCheckConfig_values(t *testing.T) {
+	content := `format: ymlx
+encoding: EBCDIC
+encodings:
+  stringz: UTF-16
+locale_filename: <locale.nmae>.yml
+sources:
+  - files: does_not_exist/<locale.code>/*.json
+  - files: "*.json"
+    tags: [ok, not ok]
+targets:
+  - format: xml
+    file: res/values-<locale.code>/strings.xml
+`
+	if errs := testCheckConfig(t, ".phrase.yml", content, false); len(errs) != 0 {
+		t.Errorf("checkConfig should only check values when asked to, got %v", errs)
+	}
+	errs := testCheckConfig(t, ".phrase.yml", content, true)
+	want := []string{"Line 1: Unrecognized format", "Line 2: Unsupported encoding", "Line 4: Unrecognized format",
+		"Line 5: Unknown placeholder <locale.nmae>", "Line 7: Directory does_not_exist does not exist", "Line 9: Tag not ok is invalid"}
+	if len(errs) != len(want) {
+		t.Fatalf("checkConfig expected %d errors, got %v", len(want), errs)
+	}
+	for i, err := range errs {
+		if !strings.HasPrefix(err.Error(), want[i]) {
+			t.Errorf("checkConfig expected %q, got %q", want[i], err.Error())
+		}
+	}
+}
+
+func TestCheckConfig_directories(t *testing.T) {
+	defer os.RemoveAll(testFolder)
+	prepareLocaleFiles(map[string][]byte{"en.json": []byte("{}")}, testFolder, "web", "en")
+
+	for content, want := range map[string]string{
+		"sources:\n  - files: web/<locale.code>/*.json\n":            "",
+		"target_directory: web/\nlocale_directory: <locale.code>/\n": "",
+		"sources:\n  - files: app/*.json\n":                          "Line 2: Directory app does not exist",
+		"target_directory: app/\n":                                   "Line 1: Directory app does not exist",
+		"target_directory: web/\nlocale_directory: de/\n":            "Line 2: Directory " + filepath.Join("web", "de") + " does not exist",
+		"format: go_i18n_v2\nlocale_directory: ./\n":                 "Line 2: Directory locales does not exist",
+	} {
+		node, err := parseConfigNode([]byte(content), ".phrase.yml")
+		if err != nil {
+			t.Fatalf("parseConfigNode returned error %v", err)
+		}
+		var got string
+		if errs := checkConfig(node, testFolder, true); len(errs) > 0 {
+			got = configErrors(errs).Error()
+		}
+		if got != want {
+			t.Errorf("checkConfig(%q) should check directories relative to the config, expected %q, got %q", content, want, got)
+		}
+	}
+}
+
+func TestNewConfig_yaml(t *testing.T) {
+	path := "config.phrase.yml"
+	defer os.Remove(path)
+	prepareLocaleFiles(map[string][]byte{path: []byte("secret: token\nformat: strings\nencodings:\n  strings: UTF-16\ntargets:\n  - format: xml\n    file: res/values-<locale.code>/strings.xml\n")}, ".")
+
+	config, err := NewConfig(path)
+	if err != nil {
+		t.Fatalf("NewConfig returned error: %v", err)
+	}
+	if config.Secret != "token" || config.Format != "strings" || config.Encodings["strings"] != "UTF-16" {
+		t.Errorf("NewConfig returned unexpected config %+v", config)
+	}
+	if len(config.Targets) != 1 || config.Targets[0].File != "res/values-<locale.code>/strings.xml" {
+		t.Errorf("NewConfig returned unexpected targets %+v", config.Targets)
+	}
+}
+
+func TestNewConfig_unknownKey(t *testing.T) {
+	path := "config.phrase"
+	defer os.Remove(path)
+	prepareLocaleFiles(map[string][]byte{path: []byte(`{"secret":"token","defualt_locale":"ms"}`)}, ".")
+
+	_, err := NewConfig(path)
+	if err == nil || !strings.Contains(err.Error(), "Unknown key defualt_locale") {
+		t.Errorf("NewConfig should return an error for unknown keys, got %v", err)
+	}
+}
+
+func TestConfig_SaveYAML(t *testing.T) {
+	path := "newconfig.phrase.yml"
+	defer os.Remove(path)
+
+	config, _ := NewConfig(path)
+	config.Secret = "token"
+	config.Format = "yml"
+	config.Encoding = "true"
+	if err := config.Save(); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	saved, err := NewConfig(path)
+	if err != nil {
+		t.Fatalf("NewConfig returned error: %v", err)
+	}
+	if saved.Secret != "token" || saved.Format != "yml" || saved.Encoding != "true" {
+		t.Errorf("Saved config should be read back, got %+v", saved)
+	}
+}
+
+func TestFindConfig(t *testing.T) {
+	root := filepath.Join(testFolder, "repo")
+	nested := filepath.Join(root, "web", "src")
+	os.MkdirAll(nested, 0777)
+	os.MkdirAll(filepath.Join(root, ".git"), 0777)
+	defer os.RemoveAll(testFolder)
+
+	if got := findConfig(nested); got != "" {
+		t.Errorf("findConfig should stop at the root of the repository, got %s", got)
+	}
+
+	prepareLocaleFiles(map[string][]byte{".phrase": []byte("{}"), ".phrase.yml": []byte("{}")}, root)
+	if got, want := findConfig(nested), filepath.Join("..", "..", ".phrase.yml"); got != want {
+		t.Errorf("findConfig should find the config in the parents, got %s, want %s", got, want)
+	}
+
+	prepareLocaleFiles(map[string][]byte{".phrase": []byte("{}")}, filepath.Join(root, "web"))
+	if got, want := findConfig(nested), filepath.Join("..", ".phrase"); got != want {
+		t.Errorf("findConfig should find the nearest config, got %s, want %s", got, want)
+	}
+}
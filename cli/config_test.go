@@ -5,6 +5,7 @@ import (
 	"github.com/weynsee/go-phrase/phrase"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -114,3 +115,168 @@ func TestNewConfig_Save(t *testing.T) {
 		t.Errorf("Secret got %+v, want \"newtoken\"", got)
 	}
 }
+
+func TestConfig_Valid_encoding(t *testing.T) {
+	c := Config{Format: "yml", Encoding: "EBCDIC"}
+	if err := c.Valid(); err == nil {
+		t.Error("Config with invalid encoding should not be valid")
+	}
+	c = Config{Format: "yml", Encodings: map[string]string{"strings": "EBCDIC"}}
+	if err := c.Valid(); err == nil {
+		t.Error("Config with invalid format encoding should not be valid")
+	}
+}
+
+func TestConfig_encodingForFormat(t *testing.T) {
+	c := Config{Encoding: "UTF-8", Encodings: map[string]string{"strings": "UTF-16"}}
+	if got := c.encodingForFormat("strings"); got != "UTF-16" {
+		t.Errorf("encodingForFormat expected UTF-16 for strings, got %s", got)
+	}
+	if got := c.encodingForFormat("yml"); got != "UTF-8" {
+		t.Errorf("encodingForFormat expected UTF-8 for yml, got %s", got)
+	}
+}
+
+func TestConfig_applyEnv(t *testing.T) {
+	env := map[string]string{
+		"PHRASE_SECRET":         "envtoken",
+		"PHRASE_DEFAULT_LOCALE": "de",
+		"PHRASE_CONCURRENCY":    "4",
+		"PHRASE_COMPILE_MO":     "true",
+		"PHRASE_ENCODINGS":      `{"strings":"UTF-16"}`,
+	}
+	lookup := func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+	c := &Config{Secret: "filetoken", Format: "yml"}
+	if err := c.applyEnv(lookup); err != nil {
+		t.Fatalf("applyEnv returned error: %v", err)
+	}
+	if c.Secret != "envtoken" || c.DefaultLocale != "de" || c.Concurrency != 4 || !c.CompileMO || c.Encodings["strings"] != "UTF-16" {
+		t.Errorf("Environment variables should override the config, got %+v", c)
+	}
+	if c.Format != "yml" {
+		t.Errorf("Fields without environment variables should be kept, got %s", c.Format)
+	}
+
+	env = map[string]string{"PHRASE_CONCURRENCY": "many"}
+	if err := new(Config).applyEnv(lookup); err == nil || err.Error() != "PHRASE_CONCURRENCY must be an integer" {
+		t.Errorf("applyEnv should reject values of the wrong type, got %v", err)
+	}
+}
+
+func TestNewConfig_SaveEnv(t *testing.T) {
+	path := "envconfig.phrase"
+	defer os.Remove(path)
+	ioutil.WriteFile(path, []byte(`{"secret":"filetoken","domain":"phrase"}`), 0644)
+
+	os.Setenv("PHRASE_SECRET", "envtoken")
+	defer os.Unsetenv("PHRASE_SECRET")
+	config, err := NewConfig(path)
+	if err != nil {
+		t.Fatalf("NewConfig returned error: %v", err)
+	}
+	if config.Secret != "envtoken" {
+		t.Errorf("PHRASE_SECRET should override the secret, got %s", config.Secret)
+	}
+	config.Domain = "other"
+	if err = config.Save(); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	saved := new(Config)
+	b, _ := ioutil.ReadFile(path)
+	json.Unmarshal(b, saved)
+	if saved.Secret != "filetoken" {
+		t.Errorf("Save should not write values of environment variables, got %s", saved.Secret)
+	}
+	if saved.Domain != "other" {
+		t.Errorf("Save should write changed values, got %s", saved.Domain)
+	}
+}
+
+func TestConfig_resolve(t *testing.T) {
+	c := &Config{root: filepath.Join("..", "..")}
+	if got, want := c.resolve("locales/"), filepath.Join("..", "..", "locales"); got != want {
+		t.Errorf("resolve should join relative paths to the root, got %s, want %s", got, want)
+	}
+	if got := c.resolve("/abs/locales"); got != "/abs/locales" {
+		t.Errorf("resolve should keep absolute paths, got %s", got)
+	}
+	if got := new(Config).resolve("locales/"); got != "locales/" {
+		t.Errorf("resolve should keep paths without a root, got %s", got)
+	}
+}
+
+func TestNewConfig_profile(t *testing.T) {
+	path := "profileconfig.phrase.yml"
+	defer os.Remove(path)
+	content := `secret: productiontoken
+format: yml
+encodings:
+  strings: UTF-16
+profiles:
+  staging:
+    secret: stagingtoken
+    target_directory: staging/
+`
+	ioutil.WriteFile(path, []byte(content), 0644)
+
+	config, err := newConfig(path, "staging")
+	if err != nil {
+		t.Fatalf("newConfig returned error: %v", err)
+	}
+	if config.Secret != "stagingtoken" || config.TargetDirectory != "staging/" {
+		t.Errorf("Profile should override the config, got %+v", config)
+	}
+	if config.Format != "yml" || config.Encodings["strings"] != "UTF-16" {
+		t.Errorf("Settings the profile does not set should be kept, got %+v", config)
+	}
+
+	os.Setenv("PHRASE_SECRET", "envtoken")
+	config, _ = newConfig(path, "staging")
+	os.Unsetenv("PHRASE_SECRET")
+	if config.Secret != "envtoken" {
+		t.Errorf("Environment variables should override the profile, got %s", config.Secret)
+	}
+
+	if _, err = newConfig(path, "production"); err == nil || err.Error() != "Unknown profile production" {
+		t.Errorf("newConfig should fail with unknown profiles, got %v", err)
+	}
+
+	os.Setenv("PHRASE_PROFILE", "staging")
+	config, _ = NewConfig(path)
+	os.Unsetenv("PHRASE_PROFILE")
+	if config.Secret != "stagingtoken" {
+		t.Errorf("NewConfig should use the profile of PHRASE_PROFILE, got %s", config.Secret)
+	}
+}
+
+func TestNewConfig_SaveProfile(t *testing.T) {
+	path := "profileconfig.phrase"
+	defer os.Remove(path)
+	ioutil.WriteFile(path, []byte(`{"secret":"productiontoken","profiles":{"staging":{"secret":"stagingtoken"}}}`), 0644)
+
+	config, err := newConfig(path, "staging")
+	if err != nil {
+		t.Fatalf("newConfig returned error: %v", err)
+	}
+	config.Secret = "newtoken"
+	config.Format = "strings"
+	if err = config.Save(); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	saved, err := newConfig(path, "")
+	if err != nil {
+		t.Fatalf("newConfig returned error: %v", err)
+	}
+	if saved.Secret != "productiontoken" || saved.Format != "" {
+		t.Errorf("Save should not change the settings outside of the profile, got %+v", saved)
+	}
+	staging, _ := newConfig(path, "staging")
+	if staging.Secret != "newtoken" || staging.Format != "strings" {
+		t.Errorf("Save should save changes to the profile, got %+v", staging)
+	}
+}
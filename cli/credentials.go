@@ -0,0 +1,320 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/weynsee/go-phrase/phrase"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// credentialStore keeps the auth tokens of projects out of their config
+// files, which are meant to be committed.
+type credentialStore interface {
+	get(project string) (string, error)
+	store(project, secret string) error
+	erase(project string) error
+}
+
+// credentials returns the store of the secrets of the user: the credential
+// helper of PHRASE_CREDENTIAL_HELPER or of the settings of the user, or
+// their credentials file. Like with git, the helper never comes from the
+// config file, which is committed, since it runs a command.
+func credentials() (credentialStore, error) {
+	command, ok := os.LookupEnv("PHRASE_CREDENTIAL_HELPER")
+	if !ok {
+		settings, err := readUserSettings()
+		if err != nil {
+			return nil, err
+		}
+		command = settings.CredentialHelper
+	}
+	if command != "" {
+		return &credentialHelper{command: command}, nil
+	}
+	return &credentialsFile{}, nil
+}
+
+// credentialName returns the name the secret of the config is stored under:
+// the name of its project, followed by the name of its profile, if any,
+// since profiles may share the project of the file but not its secret.
+func (c *Config) credentialName() string {
+	if c.profile != "" {
+		return c.Project + "@" + c.profile
+	}
+	return c.Project
+}
+
+// loadSecret reads the secret of the project of the config from the
+// credentials of the user, unless the config or a flag gives one. Commands
+// call it when they need the secret, so that the others never run the
+// credential helper.
+func (c *Config) loadSecret() error {
+	if c.Secret != "" || c.Project == "" {
+		return nil
+	}
+	store, err := credentials()
+	if err != nil {
+		return err
+	}
+	secret, err := store.get(c.credentialName())
+	if err != nil {
+		return fmt.Errorf("Error reading the auth token of project %s:\n\t%s", c.Project, err.Error())
+	}
+	c.Secret = secret
+	// the stored secret is not a change of the config to save
+	if c.loaded != nil {
+		c.loaded.Secret = secret
+	}
+	return nil
+}
+
+// storeSecret stores the secret of the config in its credential store,
+// under the name of its project and profile, and leaves it out of the
+// config file from then on.
+func (c *Config) storeSecret() error {
+	if c.Project == "" {
+		return errors.New("The config has no project to store the auth token for")
+	}
+	store, err := credentials()
+	if err != nil {
+		return err
+	}
+	if err = store.store(c.credentialName(), c.Secret); err != nil {
+		return err
+	}
+	// the secret is now loaded from the store, like when the config is
+	// read again
+	if c.file != nil {
+		c.file.Secret = ""
+	}
+	if c.loaded != nil {
+		c.loaded.Secret = c.Secret
+	}
+	if raw, ok := c.profiles[c.profile]; ok {
+		var profile map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &profile); err != nil {
+			return err
+		}
+		delete(profile, "secret")
+		b, err := json.Marshal(profile)
+		if err != nil {
+			return err
+		}
+		c.profiles[c.profile] = b
+	}
+	return nil
+}
+
+// sessionCredential is the name the user token of the session started by
+// login is stored under, apart from the names of projects.
+const sessionCredential = ":session"
+
+// signIn makes api send signed requests, with the user token of the
+// session started by login and the auth token of the project.
+func (c *Config) signIn(api *phrase.Client) error {
+	store, err := credentials()
+	if err != nil {
+		return err
+	}
+	token, err := store.get(sessionCredential)
+	if err != nil {
+		return err
+	}
+	if err = c.loadSecret(); err != nil {
+		return err
+	}
+	if token == "" {
+		return errors.New("Not logged in, please run go-phrase login")
+	}
+	api.AuthToken, api.ProjectAuthToken = token, c.Secret
+	return nil
+}
+
+// credentialsPath returns the path of the credentials file of the user.
+var credentialsPath = func() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "go-phrase", "credentials.json"), nil
+}
+
+// userSettings are the settings of the user, in the config.json file next
+// to their credentials file, that apply to all projects.
+type userSettings struct {
+	// Command that stores auth tokens instead of the credentials file, like git credential helpers.
+	CredentialHelper string `json:"credential_helper,omitempty"`
+}
+
+// readUserSettings returns the settings of the user, empty if they have
+// none.
+func readUserSettings() (*userSettings, error) {
+	settings := new(userSettings)
+	path, err := credentialsPath()
+	if err != nil {
+		return nil, err
+	}
+	path = filepath.Join(filepath.Dir(path), "config.json")
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return settings, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, settings); err != nil {
+		return nil, fmt.Errorf("Error parsing %s:\n\t%s", path, err.Error())
+	}
+	return settings, nil
+}
+
+// credentialsFile stores secrets in a JSON file of the user, readable only
+// by them, keyed by the name of the project.
+type credentialsFile struct{}
+
+func (f *credentialsFile) read() (string, map[string]string, error) {
+	path, err := credentialsPath()
+	if err != nil {
+		return "", nil, err
+	}
+	secrets := make(map[string]string)
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return path, secrets, nil
+	} else if err != nil {
+		return "", nil, err
+	}
+	if err = json.Unmarshal(b, &secrets); err != nil {
+		return "", nil, fmt.Errorf("Error parsing %s:\n\t%s", path, err.Error())
+	}
+	return path, secrets, nil
+}
+
+func (f *credentialsFile) write(path string, secrets map[string]string) error {
+	b, err := json.MarshalIndent(secrets, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(path, b, 0600); err != nil {
+		return err
+	}
+	// files created by older versions or by hand may be readable by others
+	return os.Chmod(path, 0600)
+}
+
+func (f *credentialsFile) get(project string) (string, error) {
+	_, secrets, err := f.read()
+	if err != nil {
+		return "", err
+	}
+	return secrets[project], nil
+}
+
+func (f *credentialsFile) store(project, secret string) error {
+	path, secrets, err := f.read()
+	if err != nil {
+		return err
+	}
+	secrets[project] = secret
+	return f.write(path, secrets)
+}
+
+func (f *credentialsFile) erase(project string) error {
+	path, secrets, err := f.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[project]; !ok {
+		return nil
+	}
+	delete(secrets, project)
+	return f.write(path, secrets)
+}
+
+// credentialHelper stores secrets with an external command, like git
+// credential helpers. The command is run with the action, get, store or
+// erase, as its last argument, and reads the attributes of the credential
+// from its standard input as key=value lines ending with a blank line:
+//
+//	project=my-project
+//	secret=YOUR_AUTH_TOKEN
+//
+// The secret is only given to store. get prints the attributes of the
+// credential it found, and nothing if it found none.
+//
+// The command is a shell command if it starts with !, which gets the
+// action as its first argument, a path if it is absolute, and the name of a
+// go-phrase-credential-<name> program otherwise.
+type credentialHelper struct {
+	command string
+}
+
+func (h *credentialHelper) run(action string, attrs map[string]string) (map[string]string, error) {
+	var cmd *exec.Cmd
+	switch {
+	case strings.HasPrefix(h.command, "!"):
+		cmd = exec.Command("sh", "-c", h.command[1:]+` "$@"`, "sh", action)
+	case filepath.IsAbs(h.command):
+		cmd = exec.Command(h.command, action)
+	default:
+		cmd = exec.Command("go-phrase-credential-"+h.command, action)
+	}
+	var input bytes.Buffer
+	for _, key := range []string{"project", "secret"} {
+		if value, ok := attrs[key]; ok {
+			fmt.Fprintf(&input, "%s=%s\n", key, value)
+		}
+	}
+	input.WriteString("\n")
+
+	var output, stderr bytes.Buffer
+	cmd.Stdin = &input
+	cmd.Stdout = &output
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("Credential helper %s failed:\n\t%s", h.command, msg)
+		}
+		return nil, fmt.Errorf("Credential helper %s failed:\n\t%s", h.command, err.Error())
+	}
+
+	res := make(map[string]string)
+	scanner := bufio.NewScanner(&output)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			break
+		}
+		if i := strings.Index(line, "="); i != -1 {
+			res[line[:i]] = line[i+1:]
+		}
+	}
+	return res, scanner.Err()
+}
+
+func (h *credentialHelper) get(project string) (string, error) {
+	res, err := h.run("get", map[string]string{"project": project})
+	if err != nil {
+		return "", err
+	}
+	return res["secret"], nil
+}
+
+func (h *credentialHelper) store(project, secret string) error {
+	_, err := h.run("store", map[string]string{"project": project, "secret": secret})
+	return err
+}
+
+func (h *credentialHelper) erase(project string) error {
+	_, err := h.run("erase", map[string]string{"project": project})
+	return err
+}
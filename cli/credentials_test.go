@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func init() {
+	// tests never touch the credentials of the user
+	credentialsPath = func() (string, error) {
+		return filepath.Join(testFolder, "credentials", "credentials.json"), nil
+	}
+}
+
+func TestCredentialsFile(t *testing.T) {
+	defer os.RemoveAll(testFolder)
+	path, _ := credentialsPath()
+	store := new(credentialsFile)
+
+	if secret, err := store.get("web"); err != nil || secret != "" {
+		t.Errorf("get should find nothing without credentials, got %q, %v", secret, err)
+	}
+	if err := store.store("web", "webtoken"); err != nil {
+		t.Fatalf("store returned error: %v", err)
+	}
+	store.store("ios", "iostoken")
+	if secret, _ := store.get("web"); secret != "webtoken" {
+		t.Errorf("get should return the stored secret, got %q", secret)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Credentials should only be readable by the user, got %v", info.Mode())
+	}
+
+	store.erase("web")
+	if secret, _ := store.get("web"); secret != "" {
+		t.Errorf("erase should remove the secret, got %q", secret)
+	}
+	if secret, _ := store.get("ios"); secret != "iostoken" {
+		t.Errorf("erase should keep the other secrets, got %q", secret)
+	}
+}
+
+func TestCredentialHelper(t *testing.T) {
+	os.MkdirAll(testFolder, 0777)
+	defer os.RemoveAll(testFolder)
+	input := filepath.Join(testFolder, "input")
+	helper := &credentialHelper{command: "!f() { cat > " + input + "; test $1 = get && echo secret=helpertoken; true; }; f"}
+
+	if err := helper.store("web", "webtoken"); err != nil {
+		t.Fatalf("store returned error: %v", err)
+	}
+	if got, _ := ioutil.ReadFile(input); string(got) != "project=web\nsecret=webtoken\n\n" {
+		t.Errorf("store should give the credential to the helper, got %q", got)
+	}
+	secret, err := helper.get("web")
+	if err != nil || secret != "helpertoken" {
+		t.Errorf("get should return the secret of the helper, got %q, %v", secret, err)
+	}
+	if got, _ := ioutil.ReadFile(input); string(got) != "project=web\n\n" {
+		t.Errorf("get should only give the project to the helper, got %q", got)
+	}
+
+	quoted := &credentialHelper{command: "!f() { echo secret=\"$1\"; }; f"}
+	if secret, _ := quoted.get("web"); secret != "get" {
+		t.Errorf("Helpers should get the action as their first argument, got %q", secret)
+	}
+
+	failing := &credentialHelper{command: "!echo locked >&2; false"}
+	if _, err = failing.get("web"); err == nil || !strings.Contains(err.Error(), "locked") {
+		t.Errorf("get should report the errors of the helper, got %v", err)
+	}
+}
+
+func TestNewConfig_credentials(t *testing.T) {
+	defer os.RemoveAll(testFolder)
+	path := filepath.Join(testFolder, ".phrase")
+	prepareLocaleFiles(map[string][]byte{".phrase": []byte(`{"project":"web"}`)}, testFolder)
+	new(credentialsFile).store("web", "webtoken")
+
+	config, err := NewConfig(path)
+	if err != nil {
+		t.Fatalf("NewConfig returned error: %v", err)
+	}
+	if config.Secret != "" {
+		t.Errorf("NewConfig should not read the secret before a command needs it, got %q", config.Secret)
+	}
+	if err = config.loadSecret(); err != nil || config.Secret != "webtoken" {
+		t.Errorf("loadSecret should read the secret of the project from the credentials, got %q, %v", config.Secret, err)
+	}
+
+	config.Format = "json"
+	config.Save()
+	if b, _ := ioutil.ReadFile(path); strings.Contains(string(b), "webtoken") {
+		t.Errorf("Save should not write the secret of the credentials, got %s", b)
+	}
+}
+
+func TestCredentials_helper(t *testing.T) {
+	defer os.RemoveAll(testFolder)
+	path, _ := credentialsPath()
+	prepareLocaleFiles(map[string][]byte{"config.json": []byte(`{"credential_helper":"!echo secret=usertoken; true"}`)}, filepath.Dir(path))
+
+	store, err := credentials()
+	if err != nil {
+		t.Fatalf("credentials returned error: %v", err)
+	}
+	if secret, _ := store.get("web"); secret != "usertoken" {
+		t.Errorf("credentials should use the credential helper of the user settings, got %q", secret)
+	}
+
+	os.Setenv("PHRASE_CREDENTIAL_HELPER", "")
+	defer os.Unsetenv("PHRASE_CREDENTIAL_HELPER")
+	if store, _ = credentials(); reflect.TypeOf(store) != reflect.TypeOf(&credentialsFile{}) {
+		t.Errorf("PHRASE_CREDENTIAL_HELPER should override the user settings, got %T", store)
+	}
+}
+
+func TestNewConfig_credentialHelper(t *testing.T) {
+	defer os.RemoveAll(testFolder)
+	path := filepath.Join(testFolder, ".phrase")
+	prepareLocaleFiles(map[string][]byte{".phrase": []byte(`{"project":"web","credential_helper":"!touch ran"}`)}, testFolder)
+
+	if _, err := NewConfig(path); err == nil {
+		t.Error("NewConfig should not accept a credential helper in the config file")
+	}
+}
+
+func TestConfig_profileSecrets(t *testing.T) {
+	defer os.RemoveAll(testFolder)
+	path := filepath.Join(testFolder, ".phrase")
+	prepareLocaleFiles(map[string][]byte{".phrase": []byte(`{"project":"web","profiles":{"staging":{"format":"json"}}}`)}, testFolder)
+
+	staging, _ := newConfig(path, "staging")
+	staging.Secret = "stagingtoken"
+	if err := staging.storeSecret(); err != nil {
+		t.Fatalf("storeSecret returned error: %v", err)
+	}
+	production, _ := newConfig(path, "")
+	production.Secret = "productiontoken"
+	production.storeSecret()
+
+	staging, _ = newConfig(path, "staging")
+	production, _ = newConfig(path, "")
+	staging.loadSecret()
+	production.loadSecret()
+	if staging.Secret != "stagingtoken" || production.Secret != "productiontoken" {
+		t.Errorf("Profiles sharing a project should have their own secrets, got %q and %q", staging.Secret, production.Secret)
+	}
+}
@@ -1,14 +1,34 @@
 /*
 Package cli allows the user to create a PhraseApp cli app.
 
-The cli app has 4 commands:
+The cli app has 12 commands:
 
-    init    Initializes a phrase project
-    pull    Download the translation files in the current project
-    push    Upload the translation files in the current project to PhraseApp
-    tags    List all the tags in the current project
+    blacklist  Check keys and locale files against the blacklisted keys
+    config     Validate the configuration file
+    init       Initializes a phrase project
+    lint       Check placeholders of translations against the default locale
+    login      Sign in to PhraseApp
+    logout     Sign out of PhraseApp
+    pull       Download the translation files in the current project
+    push       Upload the translation files in the current project to PhraseApp
+    status     Compare the local locale files with PhraseApp
+    tags       List all the tags in the current project
+    whoami     Display the user signed in to PhraseApp
+    xliff      Split or merge XLIFF files for translation hand-offs
 
 The cli implements all the commands and subcommands implemented by the
 official PhraseApp command-line client.
+
+The configuration is read from .phrase.yml, .phrase.yaml or .phrase in the
+current directory or the nearest parent up to the root of the repository,
+or from the file given with --config PATH. Every setting can be overridden
+by an environment variable named after its key, e.g. PHRASE_SECRET, and by
+the profile of the file selected with --profile NAME or PHRASE_PROFILE.
+Flags take precedence over environment variables, which take precedence
+over the profile, then the file, then defaults.
+
+Auth tokens are kept out of the config file: init stores them in the
+credentials file of the user, or with the configured credential helper,
+under the name of the project the config refers to.
 */
 package cli
@@ -0,0 +1,205 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// textEncoding is the encoding of a text file.
+type textEncoding int
+
+const (
+	encodingUTF8 textEncoding = iota
+	encodingUTF8BOM
+	encodingUTF16LE
+	encodingUTF16BE
+	encodingLatin1
+	encodingLatin9
+)
+
+var (
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16LE = []byte{0xff, 0xfe}
+	bomUTF16BE = []byte{0xfe, 0xff}
+)
+
+// encodingNames maps the names accepted in the configuration, without
+// dashes and underscores, to encodings.
+var encodingNames = map[string]textEncoding{
+	"":          encodingUTF8,
+	"utf8":      encodingUTF8,
+	"utf8bom":   encodingUTF8BOM,
+	"utf16":     encodingUTF16LE,
+	"utf16le":   encodingUTF16LE,
+	"utf16be":   encodingUTF16BE,
+	"latin1":    encodingLatin1,
+	"iso88591":  encodingLatin1,
+	"latin9":    encodingLatin9,
+	"iso885915": encodingLatin9,
+}
+
+// latin9Runes maps the bytes of ISO-8859-15 that differ from Latin-1 to
+// their characters.
+var latin9Runes = map[byte]rune{
+	0xa4: '€',
+	0xa6: 'Š',
+	0xa8: 'š',
+	0xb4: 'Ž',
+	0xb8: 'ž',
+	0xbc: 'Œ',
+	0xbd: 'œ',
+	0xbe: 'Ÿ',
+}
+
+var latin9Bytes = func() map[rune]byte {
+	bytes := make(map[rune]byte, len(latin9Runes))
+	for b, r := range latin9Runes {
+		bytes[r] = b
+	}
+	return bytes
+}()
+
+// parseEncoding returns the encoding with the given name, e.g. UTF-8,
+// UTF-16BE or ISO-8859-1. Names are case insensitive.
+func parseEncoding(name string) (textEncoding, error) {
+	key := strings.ToLower(strings.NewReplacer("-", "", "_", "", " ", "").Replace(name))
+	enc, ok := encodingNames[key]
+	if !ok {
+		return encodingUTF8, fmt.Errorf("Unsupported encoding: %s", name)
+	}
+	return enc, nil
+}
+
+// encodingHint returns the encoding that files of the format without a byte
+// order mark are read in when they are not UTF-8: the configured encoding,
+// or else the one of the format.
+func encodingHint(encoding, format string) (textEncoding, error) {
+	if f, ok := formats[format]; ok && encoding == "" {
+		encoding = f.properties().encoding
+	}
+	return parseEncoding(encoding)
+}
+
+// decodeText returns the content of a text file and its encoding. The
+// encoding is detected from the byte order mark. Files without one are
+// read as UTF-8 when they are valid UTF-8. UTF-16 and the single byte
+// encodings cannot be told apart from UTF-8 without a byte order mark, so
+// such files are only read in them when hint says so.
+func decodeText(b []byte, hint textEncoding) (string, textEncoding, error) {
+	switch {
+	case bytes.HasPrefix(b, bomUTF8):
+		b = b[len(bomUTF8):]
+		if !utf8.Valid(b) {
+			return "", encodingUTF8BOM, fmt.Errorf("Invalid UTF-8 text")
+		}
+		return string(b), encodingUTF8BOM, nil
+	case isUTF16(b):
+		enc := encodingUTF16LE
+		if bytes.HasPrefix(b, bomUTF16BE) {
+			enc = encodingUTF16BE
+		}
+		s, err := decodeUTF16(b)
+		return s, enc, err
+	case (hint == encodingUTF16LE || hint == encodingUTF16BE) && bytes.IndexByte(b, 0) != -1:
+		s, err := decodeUTF16WithoutBOM(b, hint == encodingUTF16BE)
+		return s, hint, err
+	case !utf8.Valid(b):
+		if hint != encodingLatin1 && hint != encodingLatin9 {
+			return "", encodingUTF8, fmt.Errorf("Invalid UTF-8 text, please configure the encoding of the file")
+		}
+		runes := make([]rune, len(b))
+		for i, c := range b {
+			runes[i] = rune(c)
+			if r, ok := latin9Runes[c]; ok && hint == encodingLatin9 {
+				runes[i] = r
+			}
+		}
+		return string(runes), hint, nil
+	}
+	return string(b), encodingUTF8, nil
+}
+
+func decodeUTF16WithoutBOM(b []byte, bigEndian bool) (string, error) {
+	if len(b)%2 != 0 {
+		return "", fmt.Errorf("Must have even length byte slice")
+	}
+	u16s := make([]uint16, len(b)/2)
+	for i := range u16s {
+		if bigEndian {
+			u16s[i] = uint16(b[2*i])<<8 | uint16(b[2*i+1])
+		} else {
+			u16s[i] = uint16(b[2*i+1])<<8 | uint16(b[2*i])
+		}
+	}
+	return string(utf16.Decode(u16s)), nil
+}
+
+// encodeText encodes s, with a byte order mark for the encodings that
+// need one.
+func encodeText(s string, enc textEncoding) ([]byte, error) {
+	switch enc {
+	case encodingUTF8BOM:
+		return append(append([]byte{}, bomUTF8...), s...), nil
+	case encodingUTF16LE, encodingUTF16BE:
+		bigEndian := enc == encodingUTF16BE
+		b := bomUTF16LE
+		if bigEndian {
+			b = bomUTF16BE
+		}
+		buf := append([]byte{}, b...)
+		for _, u := range utf16.Encode([]rune(s)) {
+			if bigEndian {
+				buf = append(buf, byte(u>>8), byte(u))
+			} else {
+				buf = append(buf, byte(u), byte(u>>8))
+			}
+		}
+		return buf, nil
+	case encodingLatin1:
+		buf := make([]byte, 0, len(s))
+		for _, r := range s {
+			if r > 0xff {
+				return nil, fmt.Errorf("Character %q cannot be encoded in Latin-1", r)
+			}
+			buf = append(buf, byte(r))
+		}
+		return buf, nil
+	case encodingLatin9:
+		buf := make([]byte, 0, len(s))
+		for _, r := range s {
+			if b, ok := latin9Bytes[r]; ok {
+				buf = append(buf, b)
+				continue
+			}
+			if _, replaced := latin9Runes[byte(r)]; r > 0xff || replaced {
+				return nil, fmt.Errorf("Character %q cannot be encoded in ISO-8859-15", r)
+			}
+			buf = append(buf, byte(r))
+		}
+		return buf, nil
+	}
+	return []byte(s), nil
+}
+
+// transcode converts the content of a text file to the given encoding.
+func transcode(b []byte, enc textEncoding) ([]byte, error) {
+	s, _, err := decodeText(b, enc)
+	if err != nil {
+		return nil, err
+	}
+	return encodeText(s, enc)
+}
+
+// decodeFile decodes the content of a file with c, which reads UTF-8. The
+// file is read in the encoding of its byte order mark, or else in UTF-8 or
+// in hint, see decodeText.
+func decodeFile(c codec, b []byte, hint textEncoding) (*catalog, error) {
+	s, _, err := decodeText(b, hint)
+	if err != nil {
+		return nil, err
+	}
+	return c.decode([]byte(s))
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		b    []byte
+		hint textEncoding
+		enc  textEncoding
+	}{
+		{[]byte("héllo"), encodingUTF8, encodingUTF8},
+		{append([]byte{0xef, 0xbb, 0xbf}, "héllo"...), encodingUTF8, encodingUTF8BOM},
+		{[]byte{0xff, 0xfe, 'h', 0, 0xe9, 0, 'l', 0, 'l', 0, 'o', 0}, encodingUTF8, encodingUTF16LE},
+		{[]byte{0xfe, 0xff, 0, 'h', 0, 0xe9, 0, 'l', 0, 'l', 0, 'o'}, encodingUTF8, encodingUTF16BE},
+		{[]byte{'h', 0xe9, 'l', 'l', 'o'}, encodingLatin1, encodingLatin1},
+		{[]byte{'h', 0xe9, 'l', 'l', 'o'}, encodingLatin9, encodingLatin9},
+	}
+	for _, test := range tests {
+		s, enc, err := decodeText(test.b, test.hint)
+		if err != nil {
+			t.Errorf("decodeText(%v) returned error %v", test.b, err)
+			continue
+		}
+		if s != "héllo" || enc != test.enc {
+			t.Errorf("decodeText(%v) expected héllo in %d, got %q in %d", test.b, test.enc, s, enc)
+		}
+		if b, err := encodeText(s, enc); err != nil || !bytes.Equal(b, test.b) {
+			t.Errorf("encodeText(%q, %d) expected %v, got %v (%v)", s, enc, test.b, b, err)
+		}
+	}
+}
+
+func TestDecodeText_hint(t *testing.T) {
+	s, enc, err := decodeText([]byte{0, 'h', 0, 'i'}, encodingUTF16BE)
+	if err != nil || s != "hi" || enc != encodingUTF16BE {
+		t.Errorf("decodeText should read UTF-16 without byte order mark, got %q in %d (%v)", s, enc, err)
+	}
+	s, enc, err = decodeText([]byte("hi"), encodingUTF16LE)
+	if err != nil || s != "hi" || enc != encodingUTF8 {
+		t.Errorf("decodeText should read text without zero bytes as UTF-8, got %q in %d (%v)", s, enc, err)
+	}
+}
+
+func TestDecodeText_latin9(t *testing.T) {
+	s, _, err := decodeText([]byte{0xa4, 0xbd}, encodingLatin9)
+	if err != nil || s != "€œ" {
+		t.Errorf("decodeText should read ISO-8859-15, got %q (%v)", s, err)
+	}
+	if b, err := encodeText("€œ", encodingLatin9); err != nil || !bytes.Equal(b, []byte{0xa4, 0xbd}) {
+		t.Errorf("encodeText should write ISO-8859-15, got %v (%v)", b, err)
+	}
+	if _, err := encodeText("¤", encodingLatin9); err == nil {
+		t.Error("encodeText should return an error for characters replaced in ISO-8859-15")
+	}
+}
+
+func TestDecodeText_error(t *testing.T) {
+	for _, b := range [][]byte{{0xff, 0xfe, 'h'}, {0xef, 0xbb, 0xbf, 0xff}, {'h', 0xe9}} {
+		if _, _, err := decodeText(b, encodingUTF8); err == nil {
+			t.Errorf("decodeText(%v) should return an error", b)
+		}
+	}
+}
+
+func TestEncodeText_latin1Error(t *testing.T) {
+	if _, err := encodeText("€", encodingLatin1); err == nil {
+		t.Error("encodeText should return an error for characters outside of Latin-1")
+	}
+}
+
+func TestParseEncoding(t *testing.T) {
+	tests := map[string]textEncoding{
+		"":            encodingUTF8,
+		"utf-8":       encodingUTF8,
+		"UTF-8-BOM":   encodingUTF8BOM,
+		"UTF-16":      encodingUTF16LE,
+		"utf_16be":    encodingUTF16BE,
+		"ISO-8859-1":  encodingLatin1,
+		"Latin-1":     encodingLatin1,
+		"ISO-8859-15": encodingLatin9,
+	}
+	for name, want := range tests {
+		if got, err := parseEncoding(name); err != nil || got != want {
+			t.Errorf("parseEncoding(%q) expected %d, got %d (%v)", name, want, got, err)
+		}
+	}
+	if _, err := parseEncoding("EBCDIC"); err == nil {
+		t.Error("parseEncoding should return an error for unsupported encodings")
+	}
+}
+
+func TestTranscode(t *testing.T) {
+	b, err := transcode([]byte{0xff, 0xfe, 'h', 0, 0xe9, 0}, encodingLatin1)
+	if err != nil || !bytes.Equal(b, []byte{'h', 0xe9}) {
+		t.Errorf("transcode expected %v, got %v (%v)", []byte{'h', 0xe9}, b, err)
+	}
+}
+
+func TestEncodingHint(t *testing.T) {
+	tests := []struct {
+		encoding, format string
+		want             textEncoding
+	}{
+		{"", "yml", encodingUTF8},
+		{"", "properties", encodingLatin1},
+		{"UTF-8", "properties", encodingUTF8},
+		{"ISO-8859-15", "yml", encodingLatin9},
+	}
+	for _, test := range tests {
+		if got, err := encodingHint(test.encoding, test.format); err != nil || got != test.want {
+			t.Errorf("encodingHint(%q, %q) expected %d, got %d (%v)", test.encoding, test.format, test.want, got, err)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	defer os.RemoveAll(testFolder)
+	os.MkdirAll(testFolder, 0777)
+	path := filepath.Join(testFolder, "en.yml")
+
+	changed, err := writeFileAtomic(path, []byte("en:\n  foo: bar\n"))
+	if err != nil {
+		t.Fatalf("writeFileAtomic returned error %v", err)
+	}
+	if !changed {
+		t.Error("writeFileAtomic should report a new file as changed")
+	}
+	if got, _ := ioutil.ReadFile(path); string(got) != "en:\n  foo: bar\n" {
+		t.Errorf("writeFileAtomic wrote %q", got)
+	}
+	if files, _ := ioutil.ReadDir(testFolder); len(files) != 1 {
+		t.Errorf("writeFileAtomic should not leave temporary files behind, found %d files", len(files))
+	}
+}
+
+func TestWriteFileAtomic_unchanged(t *testing.T) {
+	defer os.RemoveAll(testFolder)
+	os.MkdirAll(testFolder, 0777)
+	path := filepath.Join(testFolder, "en.yml")
+	ioutil.WriteFile(path, []byte("same"), 0600)
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	os.Chtimes(path, past, past)
+
+	changed, err := writeFileAtomic(path, []byte("same"))
+	if err != nil {
+		t.Fatalf("writeFileAtomic returned error %v", err)
+	}
+	if changed {
+		t.Error("writeFileAtomic should not report identical content as changed")
+	}
+	if info, _ := os.Stat(path); !info.ModTime().Equal(past) {
+		t.Errorf("writeFileAtomic should preserve the modification time, got %v", info.ModTime())
+	}
+}
+
+func TestWriteFileAtomic_keepsMode(t *testing.T) {
+	defer os.RemoveAll(testFolder)
+	os.MkdirAll(testFolder, 0777)
+	path := filepath.Join(testFolder, "en.yml")
+	ioutil.WriteFile(path, []byte("old"), 0600)
+
+	changed, err := writeFileAtomic(path, []byte("new"))
+	if err != nil {
+		t.Fatalf("writeFileAtomic returned error %v", err)
+	}
+	if !changed {
+		t.Error("writeFileAtomic should report different content as changed")
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("writeFileAtomic should keep the file mode, got %v", info.Mode())
+	}
+}
+
+func TestWriteFileAtomic_error(t *testing.T) {
+	if _, err := writeFileAtomic(filepath.Join(testFolder, "missing", "en.yml"), []byte("new")); err == nil {
+		t.Error("writeFileAtomic should return an error when the folder does not exist")
+	}
+}
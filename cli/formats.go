@@ -1,8 +1,11 @@
 package cli
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/weynsee/go-phrase/phrase"
+	"io/ioutil"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"unicode"
@@ -16,6 +19,55 @@ type formatProperties struct {
 	filenameFormat  string
 	targetDirectory string
 	localeExtension bool
+	// encoding the files of the format are in when they are not UTF-8 and
+	// no encoding is configured, e.g. ISO-8859-1 for Java properties
+	encoding string
+
+	// codec parses and writes the content of locale files. It is nil for
+	// formats whose content is not understood.
+	codec codec
+	// apiFormat is the format PhraseApp knows that files are converted to
+	// when they are uploaded, and from when they are downloaded, for
+	// formats it does not support like go-i18n v2.
+	apiFormat string
+}
+
+// toAPI converts the content of a file to the API format of its format.
+func (p *formatProperties) toAPI(content string) (string, error) {
+	cat, err := p.codec.decode([]byte(content))
+	if err != nil {
+		return "", err
+	}
+	b, err := formats[p.apiFormat].properties().codec.encode(cat)
+	return string(b), err
+}
+
+// fromAPI converts translations downloaded in the API format back to the
+// format. The syntax, descriptions and metadata of the messages of the
+// local file are kept, new files are written in the syntax of their
+// extension.
+func (p *formatProperties) fromAPI(data, local []byte, path string) ([]byte, error) {
+	cat, err := formats[p.apiFormat].properties().codec.decode(data)
+	if err != nil {
+		return nil, err
+	}
+	cat.Syntax = strings.TrimPrefix(filepath.Ext(path), ".")
+	if cat.Syntax == "yml" {
+		cat.Syntax = syntaxYAML
+	}
+	if len(bytes.TrimSpace(local)) > 0 {
+		existing, err := p.codec.decode(local)
+		if err != nil {
+			return nil, err
+		}
+		cat.Syntax = existing.Syntax
+		for _, m := range cat.Messages {
+			if old := existing.find(m.id()); old != nil {
+				m.Description, m.Metadata = old.Description, old.Metadata
+			}
+		}
+	}
+	return p.codec.encode(cat)
 }
 
 type format interface {
@@ -41,8 +93,17 @@ func (f *defaultFormat) filenameForLocale(c *Config, l *phrase.Locale) string {
 	return replacePlaceholders(f.filenameFormat, c, l)
 }
 
+// extractLocaleFromPath matches path against the directory and filename
+// formats, e.g. phrase.<locale.name>.yml.
 func (f *defaultFormat) extractLocaleFromPath(c *phrase.Client, path string) (string, error) {
-	return "", nil
+	if f.directoryFormat == "" && f.filenameFormat == "" {
+		return "", nil
+	}
+	locale, code := localeFromPlaceholders(matchPlaceholders(f.directoryFormat, f.filenameFormat, path, nil))
+	if code && c != nil {
+		return localeNameForCode(c, locale)
+	}
+	return locale, nil
 }
 
 type xmlFormat struct {
@@ -146,6 +207,85 @@ func (f *stringsdictFormat) filenameForLocale(c *Config, l *phrase.Locale) strin
 	return "Localizable.stringsdict"
 }
 
+type arbFormat struct {
+	*formatProperties
+}
+
+func (f *arbFormat) properties() *formatProperties {
+	return f.formatProperties
+}
+
+func (f *arbFormat) directoryForLocale(c *Config, l *phrase.Locale) string {
+	return "./"
+}
+
+// filenameForLocale returns app_<locale.code>.arb, with the underscores
+// Flutter expects between the parts of the code, e.g. app_en_US.arb.
+func (f *arbFormat) filenameForLocale(c *Config, l *phrase.Locale) string {
+	name := l.Code
+	if name == "" {
+		name = l.Name
+	}
+	return fmt.Sprintf("app_%s.arb", strings.Replace(name, "-", "_", -1))
+}
+
+// arbLocaleFromPathFormat matches the locale at the end of the name of a
+// file: a lowercase language followed by a script like Hant or a region
+// like BR or 419, so that prefixes with underscores, like my_app, are not
+// taken for a part of it.
+var arbLocaleFromPathFormat = regexp.MustCompile(`_([a-z]{2,3}(?:[_-](?:[A-Z][a-z]{3}|[A-Z]{2}|\d{3}))*)\.arb$`)
+
+// extractLocaleFromPath prefers the @@locale attribute of the file to
+// its name. Files that follow a configured locale_filename do not get
+// here, their locale is taken from the pattern.
+func (f *arbFormat) extractLocaleFromPath(_ *phrase.Client, path string) (string, error) {
+	if b, err := ioutil.ReadFile(path); err == nil {
+		if cat, err := decodeFile(f.codec, b, encodingUTF8); err == nil && cat.Locale != "" {
+			return strings.Replace(cat.Locale, "_", "-", -1), nil
+		}
+	}
+	res := arbLocaleFromPathFormat.FindStringSubmatch(filepath.Base(path))
+	if res == nil {
+		return "", nil
+	}
+	return strings.Replace(res[1], "_", "-", -1), nil
+}
+
+type goI18nFormat struct {
+	*formatProperties
+}
+
+func (f *goI18nFormat) properties() *formatProperties {
+	return f.formatProperties
+}
+
+func (f *goI18nFormat) directoryForLocale(c *Config, l *phrase.Locale) string {
+	return "./"
+}
+
+// filenameForLocale returns active.<locale.code>.toml, the file go-i18n
+// loads the translations of a locale from.
+func (f *goI18nFormat) filenameForLocale(c *Config, l *phrase.Locale) string {
+	name := l.Code
+	if name == "" {
+		name = l.Name
+	}
+	return fmt.Sprintf("active.%s.toml", name)
+}
+
+var goI18nLocaleFromPathFormat = regexp.MustCompile(`^(?:active|translate)\.([^.]+)\.(?:toml|json|ya?ml)$`)
+
+// extractLocaleFromPath reads the locale from active.<locale>.toml files,
+// and from the translate.<locale>.toml files goi18n merge writes with the
+// messages that still need to be translated.
+func (f *goI18nFormat) extractLocaleFromPath(_ *phrase.Client, path string) (string, error) {
+	res := goI18nLocaleFromPathFormat.FindStringSubmatch(filepath.Base(path))
+	if res == nil {
+		return "", nil
+	}
+	return strings.Replace(res[1], "_", "-", -1), nil
+}
+
 func newDefaultFormat(f string, aware bool) format {
 	props := &formatProperties{
 		localeAware:     aware,
@@ -156,6 +296,16 @@ func newDefaultFormat(f string, aware bool) format {
 	return &defaultFormat{props}
 }
 
+func withCodec(f format, c codec) format {
+	f.properties().codec = c
+	return f
+}
+
+func withEncoding(f format, encoding string) format {
+	f.properties().encoding = encoding
+	return f
+}
+
 var formats = map[string]format{
 	"json": newDefaultFormat("json", false),
 	"csv":  newDefaultFormat("csv", false),
@@ -166,6 +316,7 @@ var formats = map[string]format{
 			targetDirectory: "locales/",
 			directoryFormat: "./<locale.name>/",
 			filenameFormat:  "<domain>.po",
+			codec:           &gettextCodec{},
 		},
 	},
 	"gettext_template": &defaultFormat{
@@ -174,10 +325,21 @@ var formats = map[string]format{
 			extensions:      []string{"pot"},
 			directoryFormat: "./",
 			filenameFormat:  "phrase.pot",
+			codec:           &gettextCodec{},
+		},
+	},
+	"ftl": &defaultFormat{
+		formatProperties: &formatProperties{
+			localeAware:     true,
+			extensions:      []string{"ftl"},
+			targetDirectory: "locales/",
+			directoryFormat: "./<locale.code>/",
+			filenameFormat:  "<domain>.ftl",
+			codec:           &fluentCodec{},
 		},
 	},
 	"ini":               newDefaultFormat("ini", false),
-	"properties":        newDefaultFormat("properties", true),
+	"properties":        withEncoding(withCodec(newDefaultFormat("properties", true), &propertiesCodec{}), "ISO-8859-1"),
 	"properties_xml":    newDefaultFormat("xml", false),
 	"plist":             newDefaultFormat("plist", true),
 	"qph":               newDefaultFormat("qph", true),
@@ -185,8 +347,8 @@ var formats = map[string]format{
 	"resx":              newDefaultFormat("resx", false),
 	"resx_windowsphone": newDefaultFormat("resx", false),
 	"windows8_resource": newDefaultFormat("resw", false),
-	"simple_json":       newDefaultFormat("json", false),
-	"nested_json":       newDefaultFormat("json", false),
+	"simple_json":       withCodec(newDefaultFormat("json", false), &jsonCodec{}),
+	"nested_json":       withCodec(newDefaultFormat("json", false), &jsonCodec{nested: true}),
 	"node_json": &defaultFormat{
 		formatProperties: &formatProperties{
 			extensions:      []string{"js"},
@@ -200,6 +362,7 @@ var formats = map[string]format{
 			localeAware:     true,
 			extensions:      []string{"strings"},
 			targetDirectory: "./",
+			codec:           &stringsCodec{},
 		},
 	},
 	"stringsdict": &stringsdictFormat{
@@ -208,6 +371,7 @@ var formats = map[string]format{
 				localeAware:     true,
 				extensions:      []string{"stringsdict"},
 				targetDirectory: "./",
+				codec:           &stringsdictCodec{},
 			},
 		},
 	},
@@ -216,6 +380,7 @@ var formats = map[string]format{
 			localeAware:     true,
 			extensions:      []string{"xml"},
 			targetDirectory: "res/",
+			codec:           &androidCodec{},
 		},
 	},
 	"xlf": &defaultFormat{
@@ -224,16 +389,25 @@ var formats = map[string]format{
 			extensions:      []string{"xlf", "xliff"},
 			filenameFormat:  "phrase.<locale.name>.xlf",
 			directoryFormat: "./",
+			codec:           &xliffCodec{},
+		},
+	},
+	"arb": &arbFormat{
+		formatProperties: &formatProperties{
+			localeAware:     true,
+			extensions:      []string{"arb"},
+			targetDirectory: "lib/l10n/",
+			codec:           &arbCodec{},
 		},
 	},
 	"tmx":                newDefaultFormat("tmx", false),
-	"yml":                newDefaultFormat("yml", true),
-	"yml_symfony":        newDefaultFormat("yml", false),
-	"yml_symfony2":       newDefaultFormat("yml", false),
+	"yml":                withCodec(newDefaultFormat("yml", true), &ymlCodec{rooted: true}),
+	"yml_symfony":        withCodec(newDefaultFormat("yml", false), &ymlCodec{}),
+	"yml_symfony2":       withCodec(newDefaultFormat("yml", false), &ymlCodec{}),
 	"php_array":          newDefaultFormat("php", false),
-	"angular_translate":  newDefaultFormat("json", false),
+	"angular_translate":  withCodec(newDefaultFormat("json", false), &jsonCodec{nested: true}),
 	"laravel":            newDefaultFormat("php", false),
-	"mozilla_properties": newDefaultFormat("properties", true),
+	"mozilla_properties": withCodec(newDefaultFormat("properties", true), &propertiesCodec{}),
 	"go_i18n": &defaultFormat{
 		formatProperties: &formatProperties{
 			extensions:      []string{"json"},
@@ -242,6 +416,15 @@ var formats = map[string]format{
 			directoryFormat: "./",
 		},
 	},
+	"go_i18n_v2": &goI18nFormat{
+		formatProperties: &formatProperties{
+			localeAware:     true,
+			extensions:      []string{"toml"},
+			targetDirectory: "locales/",
+			codec:           &goI18nCodec{},
+			apiFormat:       "nested_json",
+		},
+	},
 	"play_properties": &defaultFormat{
 		formatProperties: &formatProperties{
 			filenameFormat:  "messages.<locale.code>",
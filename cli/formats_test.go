@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"github.com/weynsee/go-phrase/phrase"
 	"net/http"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 )
@@ -50,6 +52,9 @@ func testSimpleFormat(t *testing.T, name, format string, localeAware bool) {
 	if got, _ := f.extractLocaleFromPath(&phrase.Client{}, ""); got != "" {
 		t.Errorf("%s format cannot extract locale from path", name)
 	}
+	if got, _ := f.extractLocaleFromPath(&phrase.Client{}, "locales/"+filename); got != "de" {
+		t.Errorf("%s format extractLocaleFromPath expects de, got %s", name, got)
+	}
 }
 
 func TestFormats_json(t *testing.T) {
@@ -317,3 +322,97 @@ func TestFormats_xml_directoryForLocale(t *testing.T) {
 		t.Errorf("%s format directoryForLocale expects %s, got %s", name, directory, got)
 	}
 }
+
+func TestFormats_codecs(t *testing.T) {
+	withCodec := []string{"yml", "yml_symfony", "yml_symfony2", "simple_json", "nested_json", "angular_translate",
+		"properties", "mozilla_properties", "strings", "stringsdict", "xml", "arb", "gettext", "gettext_template", "xlf", "go_i18n_v2", "ftl"}
+	for _, name := range withCodec {
+		if formats[name].properties().codec == nil {
+			t.Errorf("%s format should have a codec", name)
+		}
+	}
+	if formats["csv"].properties().codec != nil {
+		t.Error("csv format should not have a codec")
+	}
+}
+
+func TestFormats_arb(t *testing.T) {
+	f := formats["arb"]
+	name := "arb"
+	props := f.properties()
+	testExtensions(t, name, props, []string{"arb"})
+	testLocaleAware(t, name, props, true)
+	testTargetDirectory(t, name, props, "lib/l10n/")
+	testFilenameForLocale(t, name, "app_de.arb", f)
+	testDirectoryForLocale(t, name, "./", f)
+	if got := f.filenameForLocale(&Config{}, &phrase.Locale{Name: "English", Code: "en-US"}); got != "app_en_US.arb" {
+		t.Errorf("%s format filename expects app_en_US.arb, got %s", name, got)
+	}
+	for path, locale := range map[string]string{
+		"lib/l10n/app_en.arb":          "en",
+		"lib/l10n/app_pt_BR.arb":       "pt-BR",
+		"lib/l10n/intl_zh_Hant_TW.arb": "zh-Hant-TW",
+		"lib/l10n/my_app_en.arb":       "en",
+		"lib/l10n/my_app_pt_BR.arb":    "pt-BR",
+		"lib/l10n/app_es_419.arb":      "es-419",
+		"lib/l10n/strings.arb":         "",
+	} {
+		if got, _ := f.extractLocaleFromPath(nil, path); got != locale {
+			t.Errorf("%s format extractLocaleFromPath(%s) expects %s, got %s", name, path, locale, got)
+		}
+	}
+}
+
+func TestFormats_arbConfiguredFilename(t *testing.T) {
+	c := &Config{LocaleFilename: "my_app_<locale.code>.arb"}
+	if got, code := c.localeFromPath("my_app_de.arb", "arb"); got != "de" || !code {
+		t.Errorf("arb files should match the code of the configured filename, got %q", got)
+	}
+}
+
+func TestFormats_arbLocaleAttribute(t *testing.T) {
+	defer os.RemoveAll(testFolder)
+	prepareLocaleFiles(map[string][]byte{"app_fr.arb": []byte(`{"@@locale": "fr_CA"}`)}, testFolder)
+	path := filepath.Join(testFolder, "app_fr.arb")
+	if got, _ := formats["arb"].extractLocaleFromPath(nil, path); got != "fr-CA" {
+		t.Errorf("arb format should prefer @@locale to the filename, got %s", got)
+	}
+}
+
+func TestFormats_goI18nV2(t *testing.T) {
+	f := formats["go_i18n_v2"]
+	name := "go_i18n_v2"
+	props := f.properties()
+	testExtensions(t, name, props, []string{"toml"})
+	testLocaleAware(t, name, props, true)
+	testTargetDirectory(t, name, props, "locales/")
+	testFilenameForLocale(t, name, "active.de.toml", f)
+	testDirectoryForLocale(t, name, "./", f)
+	for path, locale := range map[string]string{
+		"locales/active.en.toml":       "en",
+		"locales/translate.pt-BR.toml": "pt-BR",
+		"locales/active.zh_Hant.json":  "zh-Hant",
+		"active.es.yaml":               "es",
+		"locales/en.toml":              "",
+	} {
+		if got, _ := f.extractLocaleFromPath(nil, path); got != locale {
+			t.Errorf("%s format extractLocaleFromPath(%s) expects %s, got %s", name, path, locale, got)
+		}
+	}
+}
+
+func TestFormats_ftl(t *testing.T) {
+	f := formats["ftl"]
+	name := "ftl"
+	props := f.properties()
+	testExtensions(t, name, props, []string{"ftl"})
+	testLocaleAware(t, name, props, true)
+	testTargetDirectory(t, name, props, "locales/")
+	testFilenameForLocale(t, name, "testing.ftl", f)
+	if got := f.directoryForLocale(&Config{}, &phrase.Locale{Name: "English", Code: "en-US"}); got != "./en-US/" {
+		t.Errorf("%s format directoryForLocale expects ./en-US/, got %s", name, got)
+	}
+	if got, _ := f.extractLocaleFromPath(nil, "locales/pt-BR/main.ftl"); got != "pt-BR" {
+		t.Errorf("%s format extractLocaleFromPath expects pt-BR, got %s", name, got)
+	}
+}
@@ -8,7 +8,8 @@ import (
 	"strings"
 )
 
-// InitCommand will initialize a PhraseApp config file named .phrase in the current directory.
+// InitCommand will initialize a PhraseApp config file named .phrase in the current directory,
+// with a reference to the project whose auth token is stored in the credentials of the user.
 type InitCommand struct {
 	UI     mcli.Ui
 	Config *Config
@@ -27,15 +28,49 @@ func (c *InitCommand) Run(args []string) int {
 	cmdFlags.StringVar(&config.LocaleDirectory, "locale-directory", config.LocaleDirectory, "")
 	cmdFlags.StringVar(&config.LocaleFilename, "locale-filename", config.LocaleFilename, "")
 	cmdFlags.StringVar(&config.TargetDirectory, "default-target", config.TargetDirectory, "")
+	cmdFlags.StringVar(&config.Project, "project", config.Project, "")
+	var interactive bool
+	cmdFlags.BoolVar(&interactive, "interactive", false, "")
 	if err := cmdFlags.Parse(args); err != nil {
 		return 1
 	}
 
+	// the stored auth token of the project is kept, unless a new one is given
+	if err := config.loadSecret(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if interactive {
+		if err := c.wizard(); err != nil {
+			c.UI.Error(fmt.Sprintf("Error encountered while initializing the project: %s", err.Error()))
+			return 1
+		}
+	}
+
 	if config.Secret == "" {
 		c.UI.Error("No auth token was given")
 		c.UI.Error("Please provide the --secret=YOUR_SECRET parameter.")
+		c.UI.Error("Or run go-phrase init --interactive to be asked for it.")
+		return 1
+	}
+
+	// the secret is stored for the user, out of the config file, under the
+	// slug of the project like the wizard does
+	if config.Project == "" {
+		c.API.AuthToken = config.Secret
+		project, err := c.API.Projects.Current()
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error encountered while fetching the project: %s", err.Error()))
+			return 1
+		}
+		config.Project = project.Slug
+	}
+	if err := config.storeSecret(); err != nil {
+		c.UI.Error(fmt.Sprintf("Error encountered while storing the auth token: %s", err.Error()))
 		return 1
 	}
+	c.UI.Output(fmt.Sprintf("Stored the auth token of project %s", config.Project))
 
 	if err := config.Save(); err != nil {
 		c.UI.Error(fmt.Sprintf("Error encountered while saving the file: %s", err.Error()))
@@ -65,6 +100,8 @@ func (c *InitCommand) Help() string {
 	  Initializes your project for use with phrase. It will create a default
 	  locale if it does not exist yet. In addition, it will create a .phrase
 	  config file that contains your client project configuration locally.
+	  The auth token is stored in the credentials of the user, or given to the
+	  configured credential helper, and .phrase only refers to the project.
 
 	Options:
 
@@ -75,6 +112,9 @@ func (c *InitCommand) Help() string {
 	  --locale-directory=./                The directory naming for locale files, e.g ./<locale.name>/ for subfolders with 'en' or 'de'
 	  --locale-filename=<domain>.<format>  The filename for locale files
 	  --default-target=phrase/locales/     The default target directory for locale files
+	  --project=NAME                       The name the auth token is stored under (default is the slug of the project)
+	  --interactive                        Ask for the auth token, default locale, format and paths, proposing the
+	                                       locales of the project and the format of the files in the working tree
 	`
 	return strings.TrimSpace(helpText)
 }
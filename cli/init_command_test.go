@@ -1,15 +1,23 @@
 package cli
 
 import (
+	"encoding/json"
+	"fmt"
 	mcli "github.com/mitchellh/cli"
+	"io/ioutil"
+	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
 
 func TestInitCommand_Run(t *testing.T) {
 	setupAPI()
-	defer shutdownAPI()
+	defer tearDown()
+	mux.HandleFunc("/projects/current", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":1,"name":"Web App","slug":"web-app"}`)
+	})
 
 	ui := new(mcli.MockUi)
 	path := ".testing"
@@ -34,6 +42,38 @@ func TestInitCommand_Run(t *testing.T) {
 	if got := client.AuthToken; got != "secrettoken" {
 		t.Errorf("API.AuthToken should be set to %s, was %s", "secrettoken", got)
 	}
+
+	if got := config.Project; got != "web-app" {
+		t.Errorf("Config.Project should be the slug of the project, was %s", got)
+	}
+	if secret, _ := new(credentialsFile).get("web-app"); secret != "secrettoken" {
+		t.Errorf("Init should store the secret under the slug of the project, got %q", secret)
+	}
+}
+
+func TestInitCommand_Run_storesSecret(t *testing.T) {
+	setupAPI()
+	defer tearDown()
+
+	ui := new(mcli.MockUi)
+	path := filepath.Join(testFolder, ".phrase")
+	prepareLocaleFiles(map[string][]byte{".phrase": []byte(`{"secret":"plaintext"}`)}, testFolder)
+	config, _ := NewConfig(path)
+
+	c := &InitCommand{UI: ui, Config: config, API: client}
+	if code := c.Run([]string{"--secret=secrettoken", "--project=web"}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+
+	saved := new(Config)
+	b, _ := ioutil.ReadFile(path)
+	json.Unmarshal(b, saved)
+	if saved.Secret != "" || saved.Project != "web" {
+		t.Errorf("Init should only save the project to the config file, got %s", b)
+	}
+	if secret, _ := new(credentialsFile).get("web"); secret != "secrettoken" {
+		t.Errorf("Init should store the secret in the credentials, got %q", secret)
+	}
 }
 
 func TestInitCommand_Run_noSecretError(t *testing.T) {
@@ -50,9 +90,10 @@ func TestInitCommand_Run_noSecretError(t *testing.T) {
 }
 
 func TestInitCommand_Run_saveError(t *testing.T) {
+	defer os.RemoveAll(testFolder)
 	ui := new(mcli.MockUi)
 	c := &InitCommand{UI: ui, Config: &Config{}}
-	code := c.Run([]string{"--secret=this"})
+	code := c.Run([]string{"--secret=this", "--project=web"})
 
 	if code != 1 {
 		t.Fatal("Run should fail")
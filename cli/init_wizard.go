@@ -0,0 +1,273 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/weynsee/go-phrase/phrase"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// wizard asks for the settings of the config, proposing the current ones
+// or the ones found in the project and the working tree.
+func (c *InitCommand) wizard() error {
+	if err := c.askSecret(); err != nil {
+		return err
+	}
+	locales, err := c.API.Locales.ListAll()
+	if err != nil {
+		return err
+	}
+	if err = c.askDefaultLocale(locales); err != nil {
+		return err
+	}
+	if err = c.askFormat(); err != nil {
+		return err
+	}
+	return c.askPaths(locales)
+}
+
+// ask asks the query, with def as the answer if none is given.
+func (c *InitCommand) ask(query, def string) (string, error) {
+	if def != "" {
+		query = fmt.Sprintf("%s [%s]", query, def)
+	}
+	answer, err := c.UI.Ask(query + ":")
+	if err != nil {
+		return "", err
+	}
+	if answer = strings.TrimSpace(answer); answer == "" {
+		return def, nil
+	}
+	return answer, nil
+}
+
+// askSecret asks for the auth token until it is one of a project.
+func (c *InitCommand) askSecret() error {
+	config := c.Config
+	for {
+		if config.Secret == "" {
+			secret, err := c.UI.AskSecret("Auth token:")
+			if err != nil {
+				return err
+			}
+			if config.Secret = strings.TrimSpace(secret); config.Secret == "" {
+				continue
+			}
+		}
+		c.API.AuthToken = config.Secret
+		project, err := c.API.Projects.Current()
+		if err == nil {
+			c.UI.Output(fmt.Sprintf("Project: %s", project.Name))
+			if config.Project == "" {
+				config.Project = project.Slug
+			}
+			return nil
+		}
+		c.UI.Error(fmt.Sprintf("Invalid auth token: %s", err.Error()))
+		config.Secret = ""
+	}
+}
+
+// askDefaultLocale asks for the default locale, by name or by its number
+// in the list of the locales of the project.
+func (c *InitCommand) askDefaultLocale(locales []phrase.Locale) error {
+	def := c.Config.DefaultLocale
+	if len(locales) > 0 {
+		c.UI.Output("Locales of the project:")
+	}
+	for i, locale := range locales {
+		var suffix string
+		if locale.Default {
+			def, suffix = locale.Name, " (default)"
+		}
+		c.UI.Output(fmt.Sprintf("  %d) %s%s", i+1, locale.Name, suffix))
+	}
+	answer, err := c.ask("Default locale", def)
+	if err != nil {
+		return err
+	}
+	if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(locales) {
+		answer = locales[n-1].Name
+	}
+	c.Config.DefaultLocale = answer
+	return nil
+}
+
+// askFormat asks for the format of the locale files, proposing the one
+// with the most files in the working tree.
+func (c *InitCommand) askFormat() error {
+	found := detectFormats(filepath.Dir(c.Config.path))
+	def := c.Config.Format
+	if len(found) > 0 {
+		counts := make([]string, len(found))
+		for i, f := range found {
+			counts[i] = fmt.Sprintf("%s (%d)", f.name, f.files)
+		}
+		c.UI.Output(fmt.Sprintf("Found locale files: %s", strings.Join(counts, ", ")))
+		if def == "" {
+			def = found[0].name
+		}
+	}
+	if def == "" {
+		def = defaultDownloadFormat
+	}
+	for {
+		answer, err := c.ask("Format", def)
+		if err != nil {
+			return err
+		}
+		if _, ok := formats[answer]; ok {
+			c.Config.Format = answer
+			return nil
+		}
+		c.UI.Error("Unrecognized format: " + answer)
+	}
+}
+
+// askPaths asks for the templates of the paths of the locale files, until
+// the paths they give to the locales of the project are confirmed. The
+// templates of the format are left out of the config.
+func (c *InitCommand) askPaths(locales []phrase.Locale) error {
+	config := c.Config
+	props := formats[config.Format].properties()
+	templates := []struct {
+		query string
+		value *string
+		def   string
+	}{
+		{"Target directory", &config.TargetDirectory, props.targetDirectory},
+		{"Locale directory", &config.LocaleDirectory, props.directoryFormat},
+		{"Locale filename", &config.LocaleFilename, props.filenameFormat},
+	}
+	if templates[0].def == "" {
+		templates[0].def = "phrase/locales/"
+	}
+	if len(locales) == 0 {
+		locales = []phrase.Locale{{Name: config.DefaultLocale, Code: config.DefaultLocale}}
+	}
+	for {
+		for _, t := range templates {
+			current := *t.value
+			if current == "" {
+				current = t.def
+			}
+			answer, err := c.ask(t.query, current)
+			if err != nil {
+				return err
+			}
+			if answer == t.def {
+				answer = ""
+			}
+			*t.value = answer
+		}
+		if err := config.Valid(); err != nil {
+			c.UI.Error(err.Error())
+			continue
+		}
+
+		c.UI.Output("Locale files will be at:")
+		for i, locale := range locales {
+			if i == 3 {
+				c.UI.Output(fmt.Sprintf("  ... and %d more", len(locales)-i))
+				break
+			}
+			_, path := localePath(config.ForLocale(&locale))
+			c.UI.Output(fmt.Sprintf("  %s: %s", locale.Name, path))
+		}
+		answer, err := c.ask("Use these paths? (y/n)", "y")
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(strings.ToLower(answer), "y") {
+			return nil
+		}
+	}
+}
+
+// formatFiles is the number of files of a format.
+type formatFiles struct {
+	name  string
+	files int
+}
+
+// detectFormats returns the formats of the locale files below dir, the ones
+// with the most files first. Only files whose path tells a locale count, so
+// that files like package.json do not. Hidden directories and directories
+// of dependencies are skipped.
+func detectFormats(dir string) []formatFiles {
+	counts := make(map[string]int)
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		name := info.Name()
+		if info.IsDir() {
+			if path != dir && ignoredDirectory(name) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !localeInPath(dir, path) {
+			return nil
+		}
+		if format := guessFormatFromFileExtension(name); format != "" {
+			counts[format]++
+		}
+		return nil
+	})
+	found := make([]formatFiles, 0, len(counts))
+	for name, n := range counts {
+		found = append(found, formatFiles{name, n})
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].files != found[j].files {
+			return found[i].files > found[j].files
+		}
+		return found[i].name < found[j].name
+	})
+	return found
+}
+
+// ignoredDirectory returns whether the files of a directory are left out
+// when looking for locale files: hidden directories and directories of
+// dependencies.
+func ignoredDirectory(name string) bool {
+	return strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor"
+}
+
+// localeCode matches locale codes as they appear in the paths of locale
+// files, like de, pt_BR, pt-rBR, zh-Hans or es-419. Languages of three
+// letters need a region, so that names like app or pom do not match.
+var localeCode = regexp.MustCompile(`^(?:[a-z]{2}(?:[_-](?:[A-Z][a-z]{3}|r?[A-Z]{2}|\d{3}))*|[a-z]{3}(?:[_-](?:[A-Z][a-z]{3}|r?[A-Z]{2}|\d{3}))+)$`)
+
+// localeInPath returns whether the path of a file below dir tells a locale:
+// in a part of its name, like de.json, active.de.toml or app_de.arb, or in
+// the name of a directory, like de/, values-de/ or de.lproj/.
+func localeInPath(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	name := parts[len(parts)-1]
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	candidates := strings.Split(name, ".")
+	for i := range name {
+		if name[i] == '_' {
+			candidates = append(candidates, name[i+1:])
+		}
+	}
+	for _, part := range parts[:len(parts)-1] {
+		candidates = append(candidates, strings.TrimPrefix(strings.TrimSuffix(part, ".lproj"), "values-"))
+	}
+	for _, candidate := range candidates {
+		if localeCode.MatchString(candidate) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	mcli "github.com/mitchellh/cli"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestInitCommand_interactive(t *testing.T) {
+	os.RemoveAll(testFolder)
+	setupAPI()
+	defer tearDown()
+
+	mux.HandleFunc("/projects/current", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("auth_token") != "goodtoken" {
+			w.WriteHeader(401)
+			fmt.Fprint(w, `{"success":false,"message":"Unauthorized"}`)
+			return
+		}
+		fmt.Fprint(w, `{"id":1,"name":"Web App","slug":"web-app"}`)
+	})
+	mux.HandleFunc("/locales", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":1,"name":"en","code":"en","is_default":true},{"id":2,"name":"de","code":"de"}]`)
+	})
+	prepareLocaleFiles(map[string][]byte{"en.strings": []byte(""), "de.strings": []byte(""), "fr.strings": []byte("")}, testFolder, "ios")
+	prepareLocaleFiles(map[string][]byte{"en.yml": []byte("")}, testFolder, "config")
+	prepareLocaleFiles(map[string][]byte{"a.yml": []byte(""), "b.yml": []byte(""), "c.yml": []byte(""), "d.yml": []byte("")}, testFolder, ".git")
+	path := filepath.Join(testFolder, ".phrase")
+	config, _ := NewConfig(path)
+
+	input := strings.Join([]string{
+		"badtoken", "goodtoken", // the first token is rejected
+		"2",        // de is the second locale
+		"",         // the format found in the tree
+		"", "", "", // the paths of the format
+		"n", // which are rejected
+		"locales/", "<locale.code>.lproj/", "", "y",
+	}, "\n") + "\n"
+	ui := new(mcli.MockUi)
+	ui.InputReader = iotest.OneByteReader(strings.NewReader(input))
+	c := &InitCommand{UI: ui, Config: config, API: client}
+	if code := c.Run([]string{"--interactive"}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+
+	out := ui.OutputWriter.String()
+	for _, want := range []string{
+		"Project: Web App",
+		"  1) en (default)\n",
+		"Found locale files: strings (3), yml (1)",
+		"Format [strings]:",
+		"  de: " + filepath.Join("de.lproj", "Localizable.strings"),
+		"  de: " + filepath.Join("locales", "de.lproj", "Localizable.strings"),
+	} {
+		if strings.Index(out, want) == -1 {
+			t.Errorf("Init should display %q, got %q", want, out)
+		}
+	}
+	if err := ui.ErrorWriter.String(); strings.Index(err, "Invalid auth token") == -1 {
+		t.Errorf("Init should reject invalid auth tokens, got %q", err)
+	}
+
+	saved := make(map[string]interface{})
+	b, _ := ioutil.ReadFile(path)
+	json.Unmarshal(b, &saved)
+	want := map[string]interface{}{
+		"project":          "web-app",
+		"default_locale":   "de",
+		"domain":           "phrase",
+		"format":           "strings",
+		"target_directory": "locales/",
+		"locale_directory": "<locale.code>.lproj/",
+	}
+	if !reflect.DeepEqual(saved, want) {
+		t.Errorf("Init should save the answers, expected %v, got %v", want, saved)
+	}
+}
+
+func TestDetectFormats(t *testing.T) {
+	defer tearDown()
+	prepareLocaleFiles(map[string][]byte{"en.json": []byte(""), "de.json": []byte(""), "active.en.toml": []byte("")}, testFolder, "locales")
+	prepareLocaleFiles(map[string][]byte{"package.json": []byte("")}, testFolder, "node_modules", "dep")
+	prepareLocaleFiles(map[string][]byte{"package.json": []byte(""), "tsconfig.json": []byte(""), "pom.xml": []byte("")}, testFolder)
+	prepareLocaleFiles(map[string][]byte{"strings.xml": []byte("")}, testFolder, "res", "values-pt-rBR")
+	prepareLocaleFiles(map[string][]byte{"app_pt_BR.arb": []byte("")}, testFolder, "lib")
+
+	want := []formatFiles{{"json", 2}, {"arb", 1}, {"go_i18n_v2", 1}, {"xml", 1}}
+	if got := detectFormats(testFolder); !reflect.DeepEqual(got, want) {
+		t.Errorf("detectFormats returned %v, want %v", got, want)
+	}
+}
+
+func TestLocaleInPath(t *testing.T) {
+	for path, want := range map[string]bool{
+		"de.json":                     true,
+		"locales/active.pt-BR.toml":   true,
+		"lib/my_app_zh_Hans.arb":      true,
+		"res/values-pt-rBR/a.xml":     true,
+		"ios/de.lproj/Main.strings":   true,
+		"locales/de/LC_MESSAGES/a.po": true,
+		"package.json":                false,
+		"pom.xml":                     false,
+		"config/app.yml":              false,
+	} {
+		if got := localeInPath(testFolder, filepath.Join(testFolder, path)); got != want {
+			t.Errorf("localeInPath(%q) returned %v, want %v", path, got, want)
+		}
+	}
+}
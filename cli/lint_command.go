@@ -0,0 +1,194 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	mcli "github.com/mitchellh/cli"
+	"github.com/weynsee/go-phrase/phrase"
+	"sort"
+	"strings"
+)
+
+// LintCommand will check the placeholders of the translations in PhraseApp
+// against the ones of the default locale, for translations that would make
+// formatting fail at runtime.
+type LintCommand struct {
+	UI     mcli.Ui
+	Config *Config
+	API    *phrase.Client
+}
+
+// Run executes the lint command.
+func (c *LintCommand) Run(args []string) int {
+	cmdFlags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	cmdFlags.Usage = func() { c.UI.Output(c.Help()) }
+
+	config := c.Config
+
+	cmdFlags.StringVar(&config.Secret, "secret", config.Secret, "")
+	var defaultLocale string
+	cmdFlags.StringVar(&defaultLocale, "default-locale", "", "")
+
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	if err := config.loadSecret(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	c.API.AuthToken = config.Secret
+
+	locales, err := c.API.Locales.ListAll()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error encountered fetching the locales:\n\t%s", err.Error()))
+		return 1
+	}
+	if defaultLocale == "" {
+		defaultLocale = config.DefaultLocale
+		for _, locale := range locales {
+			if locale.Default {
+				defaultLocale = locale.Name
+			}
+		}
+	}
+
+	translations, err := c.API.Translations.ListAll()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error encountered fetching the translations:\n\t%s", err.Error()))
+		return 1
+	}
+	base, ok := translations[defaultLocale]
+	if !ok {
+		c.UI.Error(fmt.Sprintf("Default locale %s has no translations", defaultLocale))
+		return 1
+	}
+
+	names := cmdFlags.Args()
+	if len(names) == 0 {
+		for _, locale := range locales {
+			names = append(names, locale.Name)
+		}
+		sort.Strings(names)
+	}
+
+	index := indexTranslations(base)
+	var problems, checked int
+	unknown := false
+	for _, name := range names {
+		if name == defaultLocale {
+			continue
+		}
+		list, ok := translations[name]
+		if !ok {
+			c.UI.Error(fmt.Sprintf("Unknown locale %s", name))
+			unknown = true
+			continue
+		}
+		checked++
+		problems += c.lintLocale(name, list, index)
+	}
+
+	if problems > 0 {
+		c.UI.Error(fmt.Sprintf("Found %d placeholder problem(s) in %d locale(s)", problems, checked))
+		return 1
+	}
+	c.UI.Info(fmt.Sprintf("No placeholder problems found in %d locale(s)", checked))
+	if unknown {
+		return 1
+	}
+	return 0
+}
+
+// lintLocale reports the translations of a locale whose placeholders differ
+// from the ones of the default locale, and returns how many problems it
+// found. Translations of keys the default locale has no translation of, and
+// empty translations, are skipped.
+func (c *LintCommand) lintLocale(locale string, list []phrase.Translation, index map[string]phrase.Translation) int {
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Key.Name != list[j].Key.Name {
+			return list[i].Key.Name < list[j].Key.Name
+		}
+		return list[i].PluralSuffix < list[j].PluralSuffix
+	})
+	var problems int
+	for _, t := range list {
+		b, ok := lookupTranslation(index, t)
+		if !ok || t.Content == "" || b.Content == "" {
+			continue
+		}
+		expected, got := translationPlaceholders(b, t)
+		missing, extra, reordered := placeholderProblems(expected, got)
+		key := t.Key.Name
+		if t.PluralSuffix != "" {
+			key = fmt.Sprintf("%s[%s]", key, t.PluralSuffix)
+		}
+		if len(missing) > 0 {
+			c.UI.Output(fmt.Sprintf("%s: %s: missing %s", locale, key, strings.Join(missing, ", ")))
+			problems++
+		}
+		if len(extra) > 0 {
+			c.UI.Output(fmt.Sprintf("%s: %s: extra %s", locale, key, strings.Join(extra, ", ")))
+			problems++
+		}
+		if reordered {
+			c.UI.Output(fmt.Sprintf("%s: %s: reordered %s, expected %s", locale, key,
+				strings.Join(positionalPlaceholders(got), ", "), strings.Join(positionalPlaceholders(expected), ", ")))
+			problems++
+		}
+	}
+	return problems
+}
+
+// indexTranslations indexes translations by their key and plural suffix.
+func indexTranslations(list []phrase.Translation) map[string]phrase.Translation {
+	index := make(map[string]phrase.Translation, len(list))
+	for _, t := range list {
+		index[t.Key.Name+"\x00"+t.PluralSuffix] = t
+	}
+	return index
+}
+
+// lookupTranslation returns the translation of the index with the key and
+// plural suffix of t. Locales have different plural forms, and the same
+// form may not use the same placeholders in another locale, like a one form
+// without %d, so only forms of both locales are compared.
+func lookupTranslation(index map[string]phrase.Translation, t phrase.Translation) (phrase.Translation, bool) {
+	b, ok := index[t.Key.Name+"\x00"+t.PluralSuffix]
+	return b, ok
+}
+
+// translationPlaceholders returns the placeholders of two translations. The
+// ones found by PhraseApp are used if it found them for both, since they may
+// be written differently than the ones found in the content.
+func translationPlaceholders(base, t phrase.Translation) ([]string, []string) {
+	if base.Placeholders != nil && t.Placeholders != nil {
+		return base.Placeholders, t.Placeholders
+	}
+	return extractPlaceholders(base.Content), extractPlaceholders(t.Content)
+}
+
+// Help displays available options for the lint command.
+func (c *LintCommand) Help() string {
+	helpText := `
+	Usage: phrase lint [options] [LOCALE...]
+
+	  Check the placeholders of the translations in PhraseApp against the ones of
+	  the same keys in the default locale, reporting missing, extra and reordered
+	  placeholders. Checks all locales if none are given.
+
+	  Placeholders are printf ones like %s, %1$d, %{name} or %<name>s, {{name}},
+	  and {name} or ICU arguments like {count, plural, one {# item} other {# items}}.
+
+	Options:
+
+	  --default-locale=en       The locale to check against, the default locale of the project by default
+	  --secret=YOUR_AUTH_TOKEN  The Auth Token to use for this operation instead of the saved one (optional)
+	`
+	return strings.TrimSpace(helpText)
+}
+
+// Synopsis displays a synopsis of the lint command.
+func (c *LintCommand) Synopsis() string {
+	return "Check placeholders of translations against the default locale"
+}
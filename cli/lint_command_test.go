@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"fmt"
+	mcli "github.com/mitchellh/cli"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLintCommand_Help(t *testing.T) {
+	c := LintCommand{}
+	if c.Help() == "" {
+		t.Fatal("Help should not be empty")
+	}
+}
+
+func TestLintCommand_Synopsis(t *testing.T) {
+	c := LintCommand{}
+	if c.Synopsis() == "" {
+		t.Fatal("Synopsis should not be empty")
+	}
+}
+
+func setupLintAPI(translations string) {
+	setupAPI()
+	mux.HandleFunc("/translations", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, translations)
+	})
+	mux.HandleFunc("/locales", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":1,"name":"de"},{"id":2,"name":"en","is_default":true},{"id":3,"name":"fr"}]`)
+	})
+}
+
+const lintTranslations = `{
+	"en": [
+		{"content": "Hello %s, you have %d messages", "translation_key": {"name": "inbox"}},
+		{"content": "Hello {name}", "translation_key": {"name": "greeting"}},
+		{"content": "{count} item", "plural_suffix": "one", "translation_key": {"name": "items"}},
+		{"content": "{count} items", "plural_suffix": "other", "translation_key": {"name": "items"}}
+	],
+	"de": [
+		{"content": "Hallo %s, du hast %d Nachrichten", "translation_key": {"name": "inbox"}},
+		{"content": "Hallo {name}", "translation_key": {"name": "greeting"}},
+		{"content": "{count} Artikel", "plural_suffix": "one", "translation_key": {"name": "items"}},
+		{"content": "{count} Artikel", "plural_suffix": "other", "translation_key": {"name": "items"}}
+	],
+	"fr": [
+		{"content": "Vous avez %d messages, %s", "translation_key": {"name": "inbox"}},
+		{"content": "Bonjour {nom}", "translation_key": {"name": "greeting"}},
+		{"content": "articles", "plural_suffix": "many", "translation_key": {"name": "items"}},
+		{"content": "{count} article", "plural_suffix": "one", "translation_key": {"name": "items"}},
+		{"content": "articles", "plural_suffix": "other", "translation_key": {"name": "items"}},
+		{"content": "", "translation_key": {"name": "empty"}}
+	]
+}`
+
+func TestLintCommand_Run(t *testing.T) {
+	setupLintAPI(lintTranslations)
+	defer tearDown()
+
+	ui := new(mcli.MockUi)
+	c := &LintCommand{UI: ui, Config: new(Config), API: client}
+	code := c.Run([]string{})
+
+	if code == 0 {
+		t.Fatal("Lint command should return code != 0 when placeholders differ")
+	}
+	out := ui.OutputWriter.String()
+	for _, problem := range []string{
+		"fr: greeting: missing {name}",
+		"fr: greeting: extra {nom}",
+		"fr: inbox: reordered %d, %s, expected %s, %d",
+		"fr: items[other]: missing {count}",
+	} {
+		if !strings.Contains(out, problem) {
+			t.Errorf("Lint command should report %q, got %q", problem, out)
+		}
+	}
+	if strings.Contains(out, "items[many]") || strings.Contains(out, "items[one]") {
+		t.Errorf("Lint command should only compare plural forms of both locales, got %q", out)
+	}
+	if strings.Contains(out, "de:") {
+		t.Errorf("Lint command should not report consistent locales, got %q", out)
+	}
+	if err := ui.ErrorWriter.String(); !strings.Contains(err, "Found 4 placeholder problem(s) in 2 locale(s)") {
+		t.Errorf("Lint command should display a summary, got %q", err)
+	}
+}
+
+func TestLintCommand_Run_locales(t *testing.T) {
+	setupLintAPI(lintTranslations)
+	defer tearDown()
+
+	ui := new(mcli.MockUi)
+	c := &LintCommand{UI: ui, Config: new(Config), API: client}
+	code := c.Run([]string{"de", "xx"})
+
+	if code == 0 {
+		t.Fatal("Lint command should return code != 0 for unknown locales")
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "No placeholder problems found in 1 locale(s)") {
+		t.Errorf("Lint command should only check the given locales, got %q", out)
+	}
+	if err := ui.ErrorWriter.String(); !strings.Contains(err, "Unknown locale xx") {
+		t.Errorf("Lint command should report unknown locales, got %q", err)
+	}
+}
+
+func TestLintCommand_Run_placeholders(t *testing.T) {
+	setupLintAPI(`{
+		"en": [{"content": "Hello :name", "placeholders": [":name"], "translation_key": {"name": "greeting"}}],
+		"de": [{"content": "Hallo :nom", "placeholders": [":nom"], "translation_key": {"name": "greeting"}}]
+	}`)
+	defer tearDown()
+
+	ui := new(mcli.MockUi)
+	c := &LintCommand{UI: ui, Config: new(Config), API: client}
+	c.Run([]string{})
+
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "de: greeting: missing :name") {
+		t.Errorf("Lint command should use the placeholders found by PhraseApp, got %q", out)
+	}
+}
+
+func TestLintCommand_Run_noDefaultLocale(t *testing.T) {
+	setupLintAPI(lintTranslations)
+	defer tearDown()
+
+	ui := new(mcli.MockUi)
+	c := &LintCommand{UI: ui, Config: new(Config), API: client}
+	code := c.Run([]string{"--default-locale=es"})
+
+	if code == 0 {
+		t.Fatal("Lint command should return code != 0")
+	}
+	if err := ui.ErrorWriter.String(); !strings.Contains(err, "Default locale es has no translations") {
+		t.Errorf("UI should display error message, got %q", err)
+	}
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	mcli "github.com/mitchellh/cli"
+	"github.com/weynsee/go-phrase/phrase"
+	"strings"
+)
+
+// LoginCommand will sign in a user, so that user-level operations like
+// orders can be done with signed requests.
+type LoginCommand struct {
+	UI     mcli.Ui
+	Config *Config
+	API    *phrase.Client
+}
+
+// Run executes the login command.
+func (c *LoginCommand) Run(args []string) int {
+	var email string
+	cmdFlags := flag.NewFlagSet("login", flag.ContinueOnError)
+	cmdFlags.Usage = func() { c.UI.Output(c.Help()) }
+	cmdFlags.StringVar(&email, "email", "", "")
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	var err error
+	if email == "" {
+		if email, err = c.UI.Ask("Email:"); err != nil {
+			c.UI.Error(fmt.Sprintf("Error reading the email: %s", err.Error()))
+			return 1
+		}
+	}
+	password, err := c.UI.AskSecret("Password:")
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error reading the password: %s", err.Error()))
+		return 1
+	}
+
+	token, err := c.API.Sessions.Create(strings.TrimSpace(email), password)
+	if err == nil && token == "" {
+		err = errors.New("Invalid email or password")
+	}
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error encountered while logging in: %s", err.Error()))
+		return 1
+	}
+	store, err := credentials()
+	if err == nil {
+		err = store.store(sessionCredential, token)
+	}
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error encountered while storing the session: %s", err.Error()))
+		return 1
+	}
+	c.UI.Output(fmt.Sprintf("Logged in as %s", strings.TrimSpace(email)))
+	return 0
+}
+
+// Help displays available options for the login command.
+func (c *LoginCommand) Help() string {
+	helpText := `
+	Usage: phrase login [options]
+
+	  Sign in to PhraseApp with your email and password. The user token of the
+	  session is stored in the credentials of the user, and used for signed
+	  requests together with the auth token of the project.
+
+	Options:
+
+	  --email=EMAIL  The email of your account (asked for if not given)
+	`
+	return strings.TrimSpace(helpText)
+}
+
+// Synopsis displays a synopsis of the login command.
+func (c *LoginCommand) Synopsis() string {
+	return "Sign in to PhraseApp"
+}
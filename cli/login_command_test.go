@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"fmt"
+	mcli "github.com/mitchellh/cli"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLoginCommand_Run(t *testing.T) {
+	setupAPI()
+	defer tearDown()
+
+	mux.HandleFunc("/sessions", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("email") != "user@example.com" || r.FormValue("password") != "secret" {
+			t.Errorf("Login should send the email and password, got %v", r.Form)
+		}
+		fmt.Fprint(w, `{"success":true,"auth_token":"usertoken"}`)
+	})
+
+	ui := new(mcli.MockUi)
+	ui.InputReader = strings.NewReader("secret\n")
+	c := &LoginCommand{UI: ui, Config: new(Config), API: client}
+	if code := c.Run([]string{"--email=user@example.com"}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	if token, _ := new(credentialsFile).get(sessionCredential); token != "usertoken" {
+		t.Errorf("Login should store the user token, got %q", token)
+	}
+	if out := ui.OutputWriter.String(); strings.Index(out, "Logged in as user@example.com") == -1 {
+		t.Errorf("Login should display the user, got %q", out)
+	}
+}
+
+func TestLoginCommand_error(t *testing.T) {
+	setupAPI()
+	defer tearDown()
+
+	mux.HandleFunc("/sessions", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(401)
+		fmt.Fprint(w, `{"success":false}`)
+	})
+
+	ui := new(mcli.MockUi)
+	ui.InputReader = strings.NewReader("wrong\n")
+	c := &LoginCommand{UI: ui, Config: new(Config), API: client}
+	if code := c.Run([]string{"--email=user@example.com"}); code == 0 {
+		t.Fatal("Login with wrong credentials should fail")
+	}
+	if err := ui.ErrorWriter.String(); strings.Index(err, "Error encountered while logging in") == -1 {
+		t.Errorf("UI should display error message, got %q", err)
+	}
+	path, _ := credentialsPath()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Login should not store anything when it fails")
+	}
+}
+
+func TestLoginCommand_Help(t *testing.T) {
+	c := LoginCommand{}
+	if c.Help() == "" {
+		t.Fatal("Help should not be empty")
+	}
+}
+
+func TestLoginCommand_Synopsis(t *testing.T) {
+	c := LoginCommand{}
+	if c.Synopsis() == "" {
+		t.Fatal("Synopsis should not be empty")
+	}
+}
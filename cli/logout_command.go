@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"fmt"
+	mcli "github.com/mitchellh/cli"
+	"github.com/weynsee/go-phrase/phrase"
+	"strings"
+)
+
+// LogoutCommand will end the session of the user started by login.
+type LogoutCommand struct {
+	UI     mcli.Ui
+	Config *Config
+	API    *phrase.Client
+}
+
+// Run executes the logout command.
+func (c *LogoutCommand) Run(args []string) int {
+	if len(args) > 0 {
+		c.UI.Output(c.Help())
+		return 1
+	}
+	if err := c.Config.signIn(c.API); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	// the session is forgotten even if it already expired
+	if err := c.API.Sessions.Destroy(); err != nil {
+		c.UI.Warn(fmt.Sprintf("Notice: The session could not be destroyed (maybe it already expired): %s", err.Error()))
+	}
+	store, err := credentials()
+	if err == nil {
+		err = store.erase(sessionCredential)
+	}
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error encountered while removing the session: %s", err.Error()))
+		return 1
+	}
+	c.UI.Output("Logged out")
+	return 0
+}
+
+// Help displays available options for the logout command.
+func (c *LogoutCommand) Help() string {
+	helpText := `
+	Usage: phrase logout
+
+	  Sign out of PhraseApp, destroying the session started by login.
+	`
+	return strings.TrimSpace(helpText)
+}
+
+// Synopsis displays a synopsis of the logout command.
+func (c *LogoutCommand) Synopsis() string {
+	return "Sign out of PhraseApp"
+}
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"fmt"
+	mcli "github.com/mitchellh/cli"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLogoutCommand_Run(t *testing.T) {
+	setupAPI()
+	defer tearDown()
+
+	new(credentialsFile).store(sessionCredential, "usertoken")
+	var destroyed bool
+	mux.HandleFunc("/sessions", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		destroyed = r.Method == "DELETE" && strings.Contains(string(body), "auth_token=usertoken")
+		fmt.Fprint(w, `{"success":true}`)
+	})
+
+	ui := new(mcli.MockUi)
+	c := &LogoutCommand{UI: ui, Config: new(Config), API: client}
+	if code := c.Run([]string{}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	if !destroyed {
+		t.Error("Logout should destroy the session")
+	}
+	if token, _ := new(credentialsFile).get(sessionCredential); token != "" {
+		t.Errorf("Logout should remove the user token, got %q", token)
+	}
+}
+
+func TestLogoutCommand_notLoggedIn(t *testing.T) {
+	ui := new(mcli.MockUi)
+	c := &LogoutCommand{UI: ui, Config: new(Config)}
+	if code := c.Run([]string{}); code == 0 {
+		t.Fatal("Logout without a session should fail")
+	}
+	if err := ui.ErrorWriter.String(); strings.Index(err, "Not logged in") == -1 {
+		t.Errorf("UI should display error message, got %q", err)
+	}
+}
+
+func TestLogoutCommand_Help(t *testing.T) {
+	c := LogoutCommand{}
+	if c.Help() == "" {
+		t.Fatal("Help should not be empty")
+	}
+}
+
+func TestLogoutCommand_Synopsis(t *testing.T) {
+	c := LogoutCommand{}
+	if c.Synopsis() == "" {
+		t.Fatal("Synopsis should not be empty")
+	}
+}
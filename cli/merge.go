@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"bytes"
+)
+
+// mergeFiles merges the messages found in changes into the local file,
+// both written in the format understood by c. Local messages keep their
+// order and comments, new messages are appended. Files without a byte order
+// mark are read in hint if they are not UTF-8, and the result is written in
+// the encoding of the local file.
+func mergeFiles(c codec, local, changes []byte, hint textEncoding) ([]byte, error) {
+	if len(bytes.TrimSpace(changes)) == 0 {
+		return local, nil
+	}
+	if len(bytes.TrimSpace(local)) == 0 {
+		return changes, nil
+	}
+	text, enc, err := decodeText(local, hint)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := c.decode([]byte(text))
+	if err != nil {
+		return nil, err
+	}
+	src, err := decodeFile(c, changes, hint)
+	if err != nil {
+		return nil, err
+	}
+	mergeCatalogs(dst, src)
+	merged, err := c.encode(dst)
+	if err != nil {
+		return nil, err
+	}
+	return transcode(merged, enc)
+}
+
+// mergeCatalogs replaces the translations of dst with the ones found in
+// src and appends the messages of src that dst does not have. Messages of
+// dst keep their key segments and the type of their value, which PhraseApp
+// does not know about.
+func mergeCatalogs(dst, src *catalog) {
+	index := make(map[string]*message, len(dst.Messages))
+	for _, m := range dst.Messages {
+		if _, ok := index[m.id()]; !ok {
+			index[m.id()] = m
+		}
+	}
+	for _, m := range src.Messages {
+		existing := index[m.id()]
+		if existing == nil {
+			dst.Messages = append(dst.Messages, m)
+			index[m.id()] = m
+			continue
+		}
+		existing.Value = m.Value
+		existing.Plurals = m.Plurals
+		existing.Array = m.Array
+		existing.Variables = m.Variables
+		existing.Literal = m.Literal
+		existing.State = m.State
+		existing.Unverified = m.Unverified
+		if m.Comment != "" {
+			existing.Comment = m.Comment
+		}
+		if m.Description != "" {
+			existing.Description = m.Description
+		}
+		if m.Metadata != nil {
+			existing.Metadata = m.Metadata
+		}
+	}
+}
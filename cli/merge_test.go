@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"testing"
+)
+
+func TestMergeFiles_json(t *testing.T) {
+	local := []byte(`{"app":{"title":"Title","hello":"Hello"},"bye":"Bye"}`)
+	changes := []byte(`{"app":{"hello":"Hi"},"new":"New"}`)
+	got, err := mergeFiles(&jsonCodec{nested: true}, local, changes, encodingUTF8)
+	if err != nil {
+		t.Fatalf("mergeFiles returned error %v", err)
+	}
+	want := `{
+  "app": {
+    "title": "Title",
+    "hello": "Hi"
+  },
+  "bye": "Bye",
+  "new": "New"
+}
+`
+	if string(got) != want {
+		t.Errorf("mergeFiles expected %s, got %s", want, got)
+	}
+}
+
+func TestMergeFiles_emptyChanges(t *testing.T) {
+	local := []byte(`{"a":"b"}`)
+	got, err := mergeFiles(&jsonCodec{}, local, []byte(""), encodingUTF8)
+	if err != nil {
+		t.Fatalf("mergeFiles returned error %v", err)
+	}
+	if string(got) != string(local) {
+		t.Errorf("mergeFiles expected %s, got %s", local, got)
+	}
+}
+
+func TestMergeFiles_yaml(t *testing.T) {
+	local := []byte(`en:
+  # greetings
+  hello: Hello
+  title: Title
+`)
+	changes := []byte(`en:
+  hello: Hi
+  new: New
+`)
+	got, err := mergeFiles(&ymlCodec{rooted: true}, local, changes, encodingUTF8)
+	if err != nil {
+		t.Fatalf("mergeFiles returned error %v", err)
+	}
+	want := `en:
+  # greetings
+  hello: Hi
+  title: Title
+  new: New
+`
+	if string(got) != want {
+		t.Errorf("mergeFiles expected %s, got %s", want, got)
+	}
+}
+
+func TestMergeFiles_error(t *testing.T) {
+	if _, err := mergeFiles(&jsonCodec{}, []byte(`{`), []byte(`{}`), encodingUTF8); err == nil {
+		t.Error("mergeFiles should return an error for invalid JSON")
+	}
+	if _, err := mergeFiles(&ymlCodec{}, []byte("en:\n  - a\n b"), []byte("en: {}"), encodingUTF8); err == nil {
+		t.Error("mergeFiles should return an error for invalid YAML")
+	}
+}
+
+func TestMergeCatalogs(t *testing.T) {
+	dst := &catalog{Messages: []*message{
+		{Key: "a", Value: "A", Comment: "keep"},
+		{Key: "b", Value: "B"},
+	}}
+	src := &catalog{Messages: []*message{
+		{Key: "b", Plurals: map[string]string{"one": "1", "other": "n"}},
+		{Key: "c", Value: "C"},
+	}}
+	mergeCatalogs(dst, src)
+	if len(dst.Messages) != 3 {
+		t.Fatalf("mergeCatalogs expected 3 messages, got %d", len(dst.Messages))
+	}
+	if m := dst.Messages[0]; m.Value != "A" || m.Comment != "keep" {
+		t.Errorf("mergeCatalogs should keep untouched messages, got %+v", m)
+	}
+	if m := dst.Messages[1]; m.Plurals["other"] != "n" {
+		t.Errorf("mergeCatalogs should replace translations, got %+v", m)
+	}
+	if m := dst.Messages[2]; m.Key != "c" {
+		t.Errorf("mergeCatalogs should append new messages, got %+v", m)
+	}
+}
+
+func TestMergeFiles_types(t *testing.T) {
+	local := []byte(`{"count":1,"ok":true,"a.b":"dotted","hidden":"x"}`)
+	changes := []byte(`{"count":"2","a.b":"Dotted"}`)
+	got, err := mergeFiles(&jsonCodec{nested: true}, local, changes, encodingUTF8)
+	if err != nil {
+		t.Fatalf("mergeFiles returned error %v", err)
+	}
+	want := `{
+  "count": 2,
+  "ok": true,
+  "a.b": "Dotted",
+  "hidden": "x"
+}
+`
+	if string(got) != want {
+		t.Errorf("mergeFiles expected %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	moMagic      = 0x950412de
+	moHeaderSize = 28
+	// moContextSeparator separates msgctxt from msgid in the keys of MO files.
+	moContextSeparator = "\x04"
+)
+
+// compileMO compiles a gettext catalog into the binary MO format read by
+// gettext at runtime. Like msgfmt, it leaves out fuzzy and untranslated
+// messages, except for the header.
+func compileMO(cat *catalog) ([]byte, error) {
+	type entry struct{ key, value string }
+	var entries []entry
+	for _, m := range cat.Messages {
+		if m.Key != "" && m.hasFlag("fuzzy") {
+			continue
+		}
+		key := m.Key
+		if m.Context != "" {
+			key = m.Context + moContextSeparator + key
+		}
+		var value string
+		if m.Plurals != nil {
+			key += "\x00" + m.KeyPlural
+			forms := make([]int, 0, len(m.Plurals))
+			for form := range m.Plurals {
+				n, err := strconv.Atoi(form)
+				if err != nil {
+					return nil, err
+				}
+				forms = append(forms, n)
+			}
+			sort.Ints(forms)
+			for i, n := range forms {
+				if i > 0 {
+					value += "\x00"
+				}
+				value += m.Plurals[strconv.Itoa(n)]
+			}
+		} else {
+			value = m.Value
+		}
+		// the forms of untranslated plural messages are all empty
+		if strings.Trim(value, "\x00") == "" {
+			continue
+		}
+		entries = append(entries, entry{key, value})
+	}
+	// gettext looks up keys with a binary search
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+
+	n := uint32(len(entries))
+	keysOffset := uint32(moHeaderSize)
+	valuesOffset := keysOffset + 8*n
+	dataOffset := valuesOffset + 8*n
+	header := []uint32{moMagic, 0, n, keysOffset, valuesOffset, 0, dataOffset}
+
+	var table, data bytes.Buffer
+	writeString := func(s string) {
+		binary.Write(&table, binary.LittleEndian, []uint32{uint32(len(s)), dataOffset + uint32(data.Len())})
+		data.WriteString(s)
+		data.WriteByte(0)
+	}
+	for _, e := range entries {
+		writeString(e.key)
+	}
+	for _, e := range entries {
+		writeString(e.value)
+	}
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, header)
+	buf.Write(table.Bytes())
+	buf.Write(data.Bytes())
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// readMO returns the messages of a compiled MO file in the order of the file.
+func readMO(t *testing.T, b []byte) ([]string, []string) {
+	word := func(offset uint32) uint32 {
+		return binary.LittleEndian.Uint32(b[offset : offset+4])
+	}
+	if word(0) != moMagic {
+		t.Fatalf("MO file has invalid magic number %x", word(0))
+	}
+	n, keysOffset, valuesOffset := word(8), word(12), word(16)
+	var keys, values []string
+	for i := uint32(0); i < n; i++ {
+		length, offset := word(keysOffset+8*i), word(keysOffset+8*i+4)
+		keys = append(keys, string(b[offset:offset+length]))
+		length, offset = word(valuesOffset+8*i), word(valuesOffset+8*i+4)
+		values = append(values, string(b[offset:offset+length]))
+	}
+	return keys, values
+}
+
+func TestCompileMO(t *testing.T) {
+	cat := &catalog{Messages: []*message{
+		{Key: "", Value: "Language: de\n"},
+		{Key: "open", Context: "menu", Value: "Öffnen"},
+		{Key: "file", KeyPlural: "files", Plurals: map[string]string{"1": "Dateien", "0": "Datei"}},
+		{Key: "draft", Value: "Entwurf", Flags: []string{"fuzzy"}},
+		{Key: "empty"},
+		{Key: "day", KeyPlural: "days", Plurals: map[string]string{"0": "", "1": "", "2": ""}},
+	}}
+	b, err := compileMO(cat)
+	if err != nil {
+		t.Fatalf("compileMO returned error %v", err)
+	}
+	keys, values := readMO(t, b)
+	wantKeys := []string{"", "file\x00files", "menu\x04open"}
+	wantValues := []string{"Language: de\n", "Datei\x00Dateien", "Öffnen"}
+	if len(keys) != len(wantKeys) {
+		t.Fatalf("compileMO expected keys %q, got %q", wantKeys, keys)
+	}
+	for i := range keys {
+		if keys[i] != wantKeys[i] || values[i] != wantValues[i] {
+			t.Errorf("compileMO expected %q = %q, got %q = %q", wantKeys[i], wantValues[i], keys[i], values[i])
+		}
+	}
+}
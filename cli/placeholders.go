@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	printfPlaceholder   = regexp.MustCompile(`\A%(?:\{\w+\}|(?:(\d+\$)|<\w+>)?[-+0#']*(?:\d+|\*)?(?:\.(?:\d+|\*))?(?:hh|h|ll|l|q|z|j|t|L)?[sdiufFxXoeEgGaAcp@])`)
+	mustachePlaceholder = regexp.MustCompile(`\A\{\{\s*([A-Za-z_][\w.]*)\s*\}\}`)
+	icuArgument         = regexp.MustCompile(`\A\{\s*([A-Za-z_][\w.]*|\d+)\s*([,}])`)
+)
+
+// extractPlaceholders returns the placeholders of a translation in the
+// order they appear in: printf placeholders like %s or %1$d, the %{name}
+// and %<name>s of Ruby, {{name}}, and {name} or ICU arguments like
+// {count, plural, ...}, whose branches are searched for placeholders as
+// well. ICU arguments are returned as {name}, whatever their type.
+func extractPlaceholders(s string) []string {
+	placeholders, _ := scanPlaceholders(s, 0, false)
+	return placeholders
+}
+
+// scanPlaceholders returns the placeholders of s from i, up to the closing
+// brace of the ICU branch it is in, if nested, and the index after it.
+func scanPlaceholders(s string, i int, nested bool) ([]string, int) {
+	var placeholders []string
+	for i < len(s) {
+		rest := s[i:]
+		switch s[i] {
+		case '%':
+			if strings.HasPrefix(rest, "%%") {
+				i += 2
+				continue
+			}
+			// conversions followed by a letter are prose, like the %o of 20%off
+			if m := printfPlaceholder.FindString(rest); m != "" && (m[len(m)-1] == '}' || !isASCIILetter(rest, len(m))) {
+				placeholders = append(placeholders, m)
+				i += len(m)
+				continue
+			}
+		case '{':
+			if m := mustachePlaceholder.FindStringSubmatch(rest); m != nil {
+				placeholders = append(placeholders, "{{"+m[1]+"}}")
+				i += len(m[0])
+				continue
+			}
+			if m := icuArgument.FindStringSubmatch(rest); m != nil {
+				placeholders = append(placeholders, "{"+m[1]+"}")
+				i += len(m[0])
+				if m[2] == "," {
+					var branches []string
+					branches, i = scanICUArgument(s, i)
+					placeholders = append(placeholders, branches...)
+				}
+				continue
+			}
+		case '}':
+			if nested {
+				return placeholders, i + 1
+			}
+		}
+		i++
+	}
+	return placeholders, i
+}
+
+// isASCIILetter returns whether s has an ASCII letter at i.
+func isASCIILetter(s string, i int) bool {
+	return i < len(s) && (s[i] >= 'a' && s[i] <= 'z' || s[i] >= 'A' && s[i] <= 'Z')
+}
+
+// scanICUArgument returns the placeholders of the branches of the ICU
+// argument whose type starts at i, and the index after the argument.
+// Arguments that are not plural or select have no branches.
+func scanICUArgument(s string, i int) ([]string, int) {
+	end := strings.IndexAny(s[i:], ",}")
+	if end == -1 {
+		return nil, len(s)
+	}
+	kind := strings.TrimSpace(s[i : i+end])
+	i += end
+	if kind != "plural" && kind != "select" && kind != "selectordinal" {
+		// the style of the argument, like ::currency/EUR, is skipped
+		depth := 0
+		for ; i < len(s); i++ {
+			switch s[i] {
+			case '{':
+				depth++
+			case '}':
+				if depth == 0 {
+					return nil, i + 1
+				}
+				depth--
+			}
+		}
+		return nil, i
+	}
+	// the branches are selectors, like one or =0, followed by a message
+	// in braces, up to the closing brace of the argument
+	var placeholders []string
+	for i++; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			var branch []string
+			branch, i = scanPlaceholders(s, i+1, true)
+			placeholders = append(placeholders, branch...)
+			i--
+		case '}':
+			return placeholders, i + 1
+		}
+	}
+	return placeholders, i
+}
+
+// placeholderProblems compares the placeholders of a translation with the
+// ones of the translation it translates, and returns the ones it lacks, the
+// ones it adds, and whether it has the same placeholders but uses the ones
+// whose position matters, like %s, in another order.
+func placeholderProblems(base, translation []string) (missing, extra []string, reordered bool) {
+	counts := make(map[string]int)
+	for _, p := range base {
+		counts[p]++
+	}
+	for _, p := range translation {
+		counts[p]--
+	}
+	for _, p := range base {
+		if counts[p] > 0 {
+			missing = append(missing, p)
+			counts[p]--
+		}
+	}
+	for _, p := range translation {
+		if counts[p] < 0 {
+			extra = append(extra, p)
+			counts[p]++
+		}
+	}
+	if len(missing) > 0 || len(extra) > 0 {
+		return missing, extra, false
+	}
+	basePositional, positional := positionalPlaceholders(base), positionalPlaceholders(translation)
+	return nil, nil, strings.Join(basePositional, " ") != strings.Join(positional, " ")
+}
+
+// positionalPlaceholders returns the printf placeholders that take their
+// argument from their position, like %s but not %1$s or %<name>s.
+func positionalPlaceholders(placeholders []string) []string {
+	var res []string
+	for _, p := range placeholders {
+		if m := printfPlaceholder.FindStringSubmatch(p); m != nil && m[1] == "" && !strings.ContainsAny(p, "{<") {
+			res = append(res, p)
+		}
+	}
+	return res
+}
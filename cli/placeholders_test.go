@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlaceholders_extract(t *testing.T) {
+	tests := map[string][]string{
+		"Hello %s, you have %d messages":               {"%s", "%d"},
+		"%2$s has %1$.2f%% left":                       {"%2$s", "%1$.2f"},
+		"100%% done":                                   nil,
+		"Hello %{name}, %<count>05d left":              {"%{name}", "%<count>05d"},
+		"Hello {{ name }}":                             {"{{name}}"},
+		"Hello {name}":                                 {"{name}"},
+		"Paid {amount, number, ::currency/EUR} on {0}": {"{amount}", "{0}"},
+		"{count, plural, =0 {None} one {# by {author}} other {# items}}": {"{count}", "{author}"},
+		"{gender, select, male {He} other {They}} left":                  {"{gender}"},
+		"Braces {} and {1st} are text":                                   nil,
+		"50% off, 20%off the price, 5 % or less":                         nil,
+		"%d%s left, %{count}items":                                       {"%d", "%s", "%{count}"},
+	}
+	for s, want := range tests {
+		if got := extractPlaceholders(s); !reflect.DeepEqual(got, want) {
+			t.Errorf("extractPlaceholders(%q) returned %v, want %v", s, got, want)
+		}
+	}
+}
+
+func TestPlaceholders_problems(t *testing.T) {
+	tests := []struct {
+		base, translation []string
+		missing, extra    []string
+		reordered         bool
+	}{
+		{[]string{"%s", "%d"}, []string{"%s", "%d"}, nil, nil, false},
+		{[]string{"%s", "%d"}, []string{"%d", "%s"}, nil, nil, true},
+		{[]string{"%1$s", "%2$d"}, []string{"%2$d", "%1$s"}, nil, nil, false},
+		{[]string{"{name}", "{count}"}, []string{"{count}", "{name}"}, nil, nil, false},
+		{[]string{"%s", "%s"}, []string{"%s"}, []string{"%s"}, nil, false},
+		{[]string{"{name}"}, []string{"{nom}"}, []string{"{name}"}, []string{"{nom}"}, false},
+		{nil, []string{"%d"}, nil, []string{"%d"}, false},
+	}
+	for _, test := range tests {
+		missing, extra, reordered := placeholderProblems(test.base, test.translation)
+		if !reflect.DeepEqual(missing, test.missing) || !reflect.DeepEqual(extra, test.extra) || reordered != test.reordered {
+			t.Errorf("placeholderProblems(%v, %v) returned %v, %v, %v, want %v, %v, %v", test.base, test.translation,
+				missing, extra, reordered, test.missing, test.extra, test.reordered)
+		}
+	}
+}
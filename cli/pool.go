@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"errors"
+	mcli "github.com/mitchellh/cli"
+)
+
+const defaultConcurrency = 2
+
+// workerPool runs tasks with a bounded number of goroutines.
+// Each task writes to its own buffered Ui, and the buffered messages are
+// flushed to the pool's Ui in the order the tasks were added, so the output
+// of tasks running at the same time never interleaves.
+type workerPool struct {
+	ui          mcli.Ui
+	concurrency int
+	tasks       []func(mcli.Ui)
+}
+
+// newWorkerPool returns a workerPool that runs at most concurrency tasks
+// at the same time. A concurrency lower than 1 means defaultConcurrency.
+func newWorkerPool(ui mcli.Ui, concurrency int) *workerPool {
+	if concurrency < 1 {
+		concurrency = defaultConcurrency
+	}
+	return &workerPool{ui: ui, concurrency: concurrency}
+}
+
+// add schedules a task to be run by the pool.
+func (p *workerPool) add(task func(mcli.Ui)) {
+	p.tasks = append(p.tasks, task)
+}
+
+// run executes all scheduled tasks and blocks until all of them are done
+// and their output has been flushed.
+func (p *workerPool) run() {
+	gates := make(chan struct{}, p.concurrency)
+	buffers := make([]*bufferedUi, len(p.tasks))
+	done := make([]chan struct{}, len(p.tasks))
+
+	for i, task := range p.tasks {
+		buffers[i] = new(bufferedUi)
+		done[i] = make(chan struct{})
+		go func(task func(mcli.Ui), ui *bufferedUi, done chan struct{}) {
+			gates <- struct{}{}
+
+			task(ui)
+
+			// start other tasks that might still be waiting
+			<-gates
+
+			close(done)
+		}(task, buffers[i], done[i])
+	}
+
+	for i := range p.tasks {
+		<-done[i]
+		buffers[i].flush(p.ui)
+	}
+	p.tasks = nil
+}
+
+type uiMessageKind int
+
+const (
+	uiOutput uiMessageKind = iota
+	uiInfo
+	uiWarn
+	uiError
+)
+
+type uiMessage struct {
+	kind    uiMessageKind
+	message string
+}
+
+// bufferedUi is a Ui that records messages until they are flushed to
+// another Ui. It cannot ask for input, since tasks run in the background.
+type bufferedUi struct {
+	messages []uiMessage
+}
+
+var errBufferedUiAsk = errors.New("Cannot ask for input while running in the background")
+
+func (u *bufferedUi) Ask(string) (string, error) {
+	return "", errBufferedUiAsk
+}
+
+func (u *bufferedUi) AskSecret(string) (string, error) {
+	return "", errBufferedUiAsk
+}
+
+func (u *bufferedUi) Output(message string) {
+	u.messages = append(u.messages, uiMessage{uiOutput, message})
+}
+
+func (u *bufferedUi) Info(message string) {
+	u.messages = append(u.messages, uiMessage{uiInfo, message})
+}
+
+func (u *bufferedUi) Warn(message string) {
+	u.messages = append(u.messages, uiMessage{uiWarn, message})
+}
+
+func (u *bufferedUi) Error(message string) {
+	u.messages = append(u.messages, uiMessage{uiError, message})
+}
+
+func (u *bufferedUi) flush(ui mcli.Ui) {
+	for _, m := range u.messages {
+		switch m.kind {
+		case uiOutput:
+			ui.Output(m.message)
+		case uiInfo:
+			ui.Info(m.message)
+		case uiWarn:
+			ui.Warn(m.message)
+		case uiError:
+			ui.Error(m.message)
+		}
+	}
+	u.messages = nil
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"fmt"
+	mcli "github.com/mitchellh/cli"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkerPool_orderedOutput(t *testing.T) {
+	ui := new(mcli.MockUi)
+	pool := newWorkerPool(ui, 3)
+	for i := 0; i < 5; i++ {
+		n := i
+		pool.add(func(ui mcli.Ui) {
+			// later tasks finish first
+			time.Sleep(time.Duration(5-n) * time.Millisecond)
+			ui.Output(fmt.Sprintf("task %d", n))
+			if n == 2 {
+				ui.Error("task 2 failed")
+			}
+		})
+	}
+	pool.run()
+
+	want := "task 0\ntask 1\ntask 2\ntask 3\ntask 4\n"
+	if got := ui.OutputWriter.String(); got != want {
+		t.Errorf("workerPool output expected %q, got %q", want, got)
+	}
+	if got := ui.ErrorWriter.String(); strings.Index(got, "task 2 failed") == -1 {
+		t.Error("workerPool should flush errors written by tasks")
+	}
+}
+
+func TestWorkerPool_concurrency(t *testing.T) {
+	ui := new(mcli.MockUi)
+	pool := newWorkerPool(ui, 2)
+	var running, max int32
+	for i := 0; i < 6; i++ {
+		pool.add(func(mcli.Ui) {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&max)
+				if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+					break
+				}
+			}
+			time.Sleep(2 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+		})
+	}
+	pool.run()
+
+	if got := atomic.LoadInt32(&max); got > 2 {
+		t.Errorf("workerPool should run at most 2 tasks at the same time, ran %d", got)
+	}
+}
+
+func TestWorkerPool_defaultConcurrency(t *testing.T) {
+	pool := newWorkerPool(new(mcli.MockUi), 0)
+	if pool.concurrency != defaultConcurrency {
+		t.Errorf("workerPool concurrency expected %d, got %d", defaultConcurrency, pool.concurrency)
+	}
+}
+
+func TestBufferedUi_Ask(t *testing.T) {
+	ui := new(bufferedUi)
+	if _, err := ui.Ask("?"); err == nil {
+		t.Error("bufferedUi Ask should return an error")
+	}
+	if _, err := ui.AskSecret("?"); err == nil {
+		t.Error("bufferedUi AskSecret should return an error")
+	}
+}
@@ -1,14 +1,15 @@
 package cli
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	mcli "github.com/mitchellh/cli"
 	"github.com/weynsee/go-phrase/phrase"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
-	"sync"
 	"time"
 )
 
@@ -17,11 +18,16 @@ type PullCommand struct {
 	UI     mcli.Ui
 	Config *Config
 	API    *phrase.Client
+
+	// state is only loaded for incremental pulls.
+	state  *syncState
+	dryRun bool
+	// blacklist is nil if it could not be fetched.
+	blacklist *blacklist
 }
 
 const (
 	timeFormat            = "20060102150405"
-	concurrency           = 2
 	defaultDownloadFormat = "yml"
 )
 
@@ -36,6 +42,8 @@ func (c *PullCommand) Run(args []string) int {
 	cmdFlags.StringVar(&config.TargetDirectory, "target", config.TargetDirectory, "")
 	cmdFlags.StringVar(&config.Encoding, "encoding", config.Encoding, "")
 	cmdFlags.StringVar(&config.Format, "format", config.Format, "")
+	cmdFlags.IntVar(&config.Concurrency, "concurrency", config.Concurrency, "")
+	cmdFlags.BoolVar(&config.CompileMO, "compile-mo", config.CompileMO, "")
 
 	req := new(phrase.DownloadRequest)
 	cmdFlags.StringVar(&req.Tag, "tag", "", "")
@@ -44,11 +52,19 @@ func (c *PullCommand) Run(args []string) int {
 	cmdFlags.BoolVar(&req.ConvertEmoji, "convert-emoji", false, "")
 	cmdFlags.BoolVar(&req.SkipUnverifiedTranslations, "skip-unverified-translations", false, "")
 	cmdFlags.BoolVar(&req.IncludeEmptyTranslations, "include-empty-translations", false, "")
+	var incremental bool
+	cmdFlags.BoolVar(&incremental, "incremental", false, "")
+	cmdFlags.BoolVar(&c.dryRun, "dry-run", false, "")
 
 	if err := cmdFlags.Parse(args); err != nil {
 		return 1
 	}
 
+	if incremental && updatedSince != "" {
+		c.UI.Error("--incremental cannot be combined with --updated-since")
+		return 1
+	}
+
 	if updatedSince != "" {
 		var err error
 		req.UpdatedSince, err = time.Parse(timeFormat, updatedSince)
@@ -62,8 +78,12 @@ func (c *PullCommand) Run(args []string) int {
 		config.Format = defaultDownloadFormat
 	}
 
+	if err := config.loadSecret(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
 	c.API.AuthToken = config.Secret
-	req.Encoding = config.Encoding
+	req.Encoding = config.encodingForFormat(config.Format)
 	req.Format = config.Format
 
 	if err := config.Valid(); err != nil {
@@ -71,95 +91,273 @@ func (c *PullCommand) Run(args []string) int {
 		return 1
 	}
 
-	err := c.fetch(req, cmdFlags.Args())
+	if incremental {
+		var err error
+		if c.state, err = loadSyncState(config.statePath()); err != nil {
+			c.UI.Error(fmt.Sprintf("Error reading the sync state %s:\n\t%s", config.statePath(), err.Error()))
+			return 1
+		}
+	}
+
+	if !c.dryRun {
+		var err error
+		if c.blacklist, err = fetchBlacklist(c.API); err != nil {
+			c.UI.Warn(fmt.Sprintf("Could not fetch the blacklisted keys, local files are not checked for them:\n\t%s", err.Error()))
+		}
+	}
+
+	// the configured targets are pulled unless the command line asks for
+	// a single format or folder
+	explicit := false
+	cmdFlags.Visit(func(f *flag.Flag) {
+		explicit = explicit || f.Name == "format" || f.Name == "target"
+		if f.Name == "target" {
+			// unlike the config, the command line is relative to the
+			// current directory
+			config.root = ""
+		}
+	})
+	if len(config.Targets) > 0 && !explicit {
+		status := 0
+		for _, target := range config.Targets {
+			targetConfig := config.forTarget(target)
+			targetReq := target.downloadRequest(*req)
+			targetReq.Format = targetConfig.Format
+			targetReq.Encoding = targetConfig.encodingForFormat(targetConfig.Format)
+			locales := cmdFlags.Args()
+			if len(locales) == 0 {
+				locales = target.Locales
+			}
+			if err := c.fetch(targetConfig, &targetReq, locales); err != nil {
+				c.UI.Error(fmt.Sprintf("Error encountered fetching the locales of the %s target:\n\t%s", target.Format, err.Error()))
+				status = 1
+			}
+		}
+		c.reportDryRun()
+		return status
+	}
+
+	err := c.fetch(config, req, cmdFlags.Args())
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error encountered fetching the locales:\n\t%s", err.Error()))
 		return 1
 	}
+	c.reportDryRun()
 	return 0
 }
 
-func (c *PullCommand) fetch(req *phrase.DownloadRequest, locales []string) error {
-	selected, err := c.selectLocales(locales)
+func (c *PullCommand) reportDryRun() {
+	if c.dryRun {
+		c.UI.Info("Dry run: no files were downloaded")
+	}
+}
+
+func (c *PullCommand) fetch(config *Config, req *phrase.DownloadRequest, locales []string) error {
+	selected, err := selectLocales(c.API, c.UI, locales)
 	if err != nil {
 		return err
 	}
 
-	var wg sync.WaitGroup
-	wg.Add(len(selected))
-	gates := make(chan struct{}, concurrency)
+	if c.dryRun {
+		for _, locale := range selected {
+			c.planLocale(config, *req, locale)
+		}
+		return nil
+	}
 
+	pool := newWorkerPool(c.UI, config.Concurrency)
 	for _, locale := range selected {
-		go func(l phrase.Locale) {
-			<-gates
+		l := locale
+		pool.add(func(ui mcli.Ui) {
+			c.fetchLocale(config, *req, l, ui)
+		})
+	}
+	pool.run()
 
-			c.fetchLocale(*req, l)
+	if c.state != nil {
+		return c.state.save()
+	}
+	return nil
+}
 
-			// start other locales that might still be waiting
-			gates <- struct{}{}
+// planLocale displays where the locale would be downloaded to.
+func (c *PullCommand) planLocale(config *Config, req phrase.DownloadRequest, locale phrase.Locale) {
+	lc := config.ForLocale(&locale)
+	_, path := localePath(lc)
+	req.Locale = locale.Name
+	var tagged string
+	if req.Tag != "" {
+		tagged = fmt.Sprintf(", tag: %s", req.Tag)
+	}
+	if c.incrementalMerge(&req, lc, path, c.UI) != nil {
+		tagged += fmt.Sprintf(", changes since %s", req.UpdatedSince.Format(time.RFC3339))
+	}
+	c.UI.Output(fmt.Sprintf("Would download locale %s to %s (format: %s%s)", locale.Name, path, req.Format, tagged))
+}
 
-			wg.Done()
-		}(locale)
+func (c *PullCommand) fetchLocale(config *Config, req phrase.DownloadRequest, locale phrase.Locale, ui mcli.Ui) {
+	lc := config.ForLocale(&locale)
+	folder, path := localePath(lc)
+
+	req.Locale = locale.Name
+	merge := c.incrementalMerge(&req, lc, path, ui)
+	started := time.Now()
+	// formats PhraseApp does not know are downloaded in their API format
+	download := req
+	props := lc.properties()
+	if props.apiFormat != "" {
+		download.Format = props.apiFormat
+	}
+	var content bytes.Buffer
+	limit, err := c.API.Translations.Download(&download, &content)
+	if err != nil {
+		ui.Error(fmt.Sprintf("Error downloading locale %s:\n\t%s", req.Locale, err.Error()))
+		return
 	}
-	for i := 0; i < concurrency; i++ {
-		gates <- struct{}{}
+	if limit.Remaining == 0 {
+		ui.Error(fmt.Sprintf("Rate limit reached. Please try again at %v", limit.Reset))
+		return
 	}
 
-	wg.Wait()
-	return nil
+	data := content.Bytes()
+	if props.apiFormat != "" {
+		local, _ := ioutil.ReadFile(path)
+		if data, err = props.fromAPI(data, local, path); err != nil {
+			ui.Error(fmt.Sprintf("Error converting locale %s:\n\t%s", req.Locale, err.Error()))
+			return
+		}
+	}
+	if merge != nil {
+		local, err := ioutil.ReadFile(path)
+		var hint textEncoding
+		if err == nil {
+			hint, err = encodingHint(req.Encoding, req.Format)
+		}
+		if err == nil {
+			data, err = mergeFiles(merge, local, data, hint)
+		}
+		if err != nil {
+			ui.Error(fmt.Sprintf("Error merging changes into %s:\n\t%s", path, err.Error()))
+			return
+		}
+	}
+
+	c.validateLocale(lc, &locale, path, data, ui)
+	c.checkBlacklist(lc, path, data, ui)
+	if data, err = config.normalizeEncoding(req.Format, data); err != nil {
+		ui.Error(fmt.Sprintf("Error encoding %s:\n\t%s", path, err.Error()))
+		return
+	}
+
+	if err = os.MkdirAll(folder, 0777); err != nil {
+		ui.Error(fmt.Sprintf("Error creating folder %s:\n\t%s", folder, err.Error()))
+		return
+	}
+	changed, err := writeFileAtomic(path, data)
+	if err != nil {
+		ui.Error(fmt.Sprintf("Error writing file %s:\n\t%s", path, err.Error()))
+		return
+	}
+	if config.CompileMO && req.Format == "gettext" {
+		c.compileLocale(path, data, ui)
+	}
+	if c.state != nil {
+		c.state.recordPull(req.Locale, req.Format, req.Tag, path, started)
+	}
+	if !changed {
+		ui.Output(fmt.Sprintf("Unchanged %s", path))
+		return
+	}
+
+	if merge != nil {
+		ui.Output(fmt.Sprintf("Merged changes since %s into %s", req.UpdatedSince.Format(time.RFC3339), path))
+		return
+	}
+	ui.Output(fmt.Sprintf("Downloaded %s", path))
 }
 
-func (c *PullCommand) fetchLocale(req phrase.DownloadRequest, locale phrase.Locale) {
-	lc := c.Config.ForLocale(&locale)
-	folder := filepath.Join(lc.TargetDirectory, lc.LocaleDirectory)
-	err := os.MkdirAll(folder, 0777)
+// validateLocale warns about problems in the downloaded content of a locale
+// file, for formats that can check them.
+func (c *PullCommand) validateLocale(lc *LocaleConfig, locale *phrase.Locale, path string, data []byte, ui mcli.Ui) {
+	validator, ok := lc.properties().codec.(localeValidator)
+	if !ok {
+		return
+	}
+	cat, err := lc.properties().codec.decode(data)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error creating folder %s:\n\t%s", folder, err.Error()))
+		ui.Warn(fmt.Sprintf("Could not parse %s:\n\t%s", path, err.Error()))
 		return
 	}
-	path := filepath.Join(folder, lc.LocaleFilename)
-	file, err := os.Create(path)
-	defer file.Close()
+	for _, err := range validator.validate(cat, locale) {
+		ui.Warn(fmt.Sprintf("%s: %s", path, err.Error()))
+	}
+}
+
+// checkBlacklist warns about blacklisted keys in the content of a locale
+// file, e.g. local keys kept by incremental pulls, which PhraseApp ignores.
+func (c *PullCommand) checkBlacklist(lc *LocaleConfig, path string, data []byte, ui mcli.Ui) {
+	codec := lc.properties().codec
+	if c.blacklist == nil || codec == nil {
+		return
+	}
+	cat, err := codec.decode(data)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error creating file %s:\n\t%s", path, err.Error()))
 		return
 	}
+	if keys := c.blacklist.keys(cat); len(keys) > 0 {
+		ui.Warn(fmt.Sprintf("%s contains %d blacklisted key(s), which PhraseApp ignores: %s", path, len(keys), strings.Join(keys, ", ")))
+	}
+}
 
-	req.Locale = locale.Name
-	limit, err := c.API.Translations.Download(&req, file)
+// compileLocale compiles a gettext .po file into the .mo file next to it.
+func (c *PullCommand) compileLocale(path string, data []byte, ui mcli.Ui) {
+	moPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".mo"
+	cat, err := new(gettextCodec).decode(data)
+	if err == nil {
+		data, err = compileMO(cat)
+	}
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error downloading locale %s:\n\t%s", req.Locale, err.Error()))
+		ui.Error(fmt.Sprintf("Error compiling %s:\n\t%s", path, err.Error()))
 		return
 	}
-	if limit.Remaining == 0 {
-		c.UI.Error(fmt.Sprintf("Rate limit reached. Please try again at %v", limit.Reset))
+	changed, err := writeFileAtomic(moPath, data)
+	if err != nil {
+		ui.Error(fmt.Sprintf("Error writing file %s:\n\t%s", moPath, err.Error()))
 		return
 	}
-
-	c.UI.Output(fmt.Sprintf("Downloaded %s", path))
+	if changed {
+		ui.Output(fmt.Sprintf("Compiled %s", moPath))
+	}
 }
 
-func (c *PullCommand) selectLocales(locales []string) ([]phrase.Locale, error) {
-	all, err := c.API.Locales.ListAll()
-	if err != nil {
-		return nil, err
+// incrementalMerge limits req to the translations changed since the last
+// pull and returns the codec that merges them into the local file.
+// It returns nil when all translations have to be downloaded, either because
+// the pull is not incremental, or because there is nothing to merge into.
+func (c *PullCommand) incrementalMerge(req *phrase.DownloadRequest, lc *LocaleConfig, path string, ui mcli.Ui) codec {
+	if c.state == nil {
+		return nil
 	}
-	if len(locales) == 0 {
-		return all, nil
+	merge := lc.properties().codec
+	if merge == nil {
+		ui.Warn(fmt.Sprintf("Format %s cannot be merged, downloading all translations of %s", req.Format, req.Locale))
+		return nil
 	}
-	localeMap := make(map[string]phrase.Locale)
-	for _, locale := range all {
-		localeMap[locale.Name] = locale
+	since := c.state.lastPull(req.Locale, req.Format, req.Tag, path)
+	if since.IsZero() {
+		return nil
 	}
-	var selected = make([]phrase.Locale, 0, len(locales))
-	for _, locale := range locales {
-		if l, ok := localeMap[locale]; ok {
-			selected = append(selected, l)
-		} else {
-			c.UI.Warn(fmt.Sprintf("Skipping unknown locale %s", locale))
-		}
+	if _, err := os.Stat(path); err != nil {
+		return nil
 	}
-	return selected, nil
+	req.UpdatedSince = since
+	return merge
+}
+
+// localePath returns the folder and the path of the file for a locale.
+func localePath(lc *LocaleConfig) (string, string) {
+	folder := filepath.Join(lc.TargetDirectory, lc.LocaleDirectory)
+	return folder, filepath.Join(folder, lc.LocaleFilename)
 }
 
 // Help displays available options for the pull command.
@@ -175,11 +373,18 @@ func (c *PullCommand) Help() string {
         --target=./phrase/locales       Target folder to store locale files
         --tag=foo                       Limit results to a given tag instead of all translations
         --updated-since=YYYYMMDDHHMMSS  Limit results to translations updated after the given date (UTC)
+        --incremental                   Only download translations changed since the last pull and merge them into the local files
         --include-empty-translations    Include empty translations in the result
         --convert-emoji                 Convert Emoji symbols
         --encoding=utf-8                Convert .strings or .properties with alternate encoding
         --skip-unverified-translations  Skip unverified translations in the result
+        --concurrency=2                 Number of locales to download at the same time
+        --dry-run                       Only display where the locale files would be written to
+        --compile-mo                    Compile downloaded gettext .po files into binary .mo files
         --secret=YOUR_AUTH_TOKEN        The Auth Token to use for this operation instead of the saved one (optional)
+
+	  The targets configured in .phrase are downloaded unless --format or --target are given.
+	  Downloaded files that still contain keys blacklisted in PhraseApp are reported.
 	`
 	return strings.TrimSpace(helpText)
 }
@@ -1,10 +1,15 @@
 package cli
 
 import (
+	"bytes"
 	"fmt"
 	mcli "github.com/mitchellh/cli"
+	"io/ioutil"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 )
@@ -184,3 +189,326 @@ func TestPullCommand_downloadError(t *testing.T) {
 		t.Error("Pull command should print warning when rate limit has been reached.")
 	}
 }
+
+func TestPullCommand_downloadErrorKeepsFile(t *testing.T) {
+	setupAPI()
+	defer tearDown()
+
+	prepareLocaleFiles(map[string][]byte{"phrase.en.yml": []byte("existing")}, testFolder)
+
+	mux.HandleFunc("/translations/download", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, `Service Unavailable`)
+	})
+	mux.HandleFunc("/locales", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":1,"name":"en","is_default":true}]`)
+	})
+	ui := new(mcli.MockUi)
+	c := &PullCommand{UI: ui, Config: new(Config), API: client}
+	c.Run([]string{"--target=./test"})
+
+	if got, _ := ioutil.ReadFile(filepath.Join(testFolder, "phrase.en.yml")); string(got) != "existing" {
+		t.Errorf("Pull command should not touch the file when the download fails, got %q", got)
+	}
+}
+
+func TestPullCommand_rateLimitedDoesNotCreateFile(t *testing.T) {
+	setupAPI()
+	defer tearDown()
+
+	mux.HandleFunc("/translations/download", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("X-Rate-Limit-Remaining", "0")
+	})
+	mux.HandleFunc("/locales", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":1,"name":"en","is_default":true}]`)
+	})
+	ui := new(mcli.MockUi)
+	c := &PullCommand{UI: ui, Config: new(Config), API: client}
+	c.Run([]string{"--target=./test"})
+
+	if _, err := os.Stat(filepath.Join(testFolder, "phrase.en.yml")); !os.IsNotExist(err) {
+		t.Error("Pull command should not create the file when the rate limit has been reached")
+	}
+}
+
+func TestPullCommand_unchanged(t *testing.T) {
+	setupAPI()
+	defer tearDown()
+
+	prepareLocaleFiles(map[string][]byte{"phrase.en.yml": []byte("OK")}, testFolder)
+
+	mux.HandleFunc("/translations/download", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("X-Rate-Limit-Remaining", "59")
+		fmt.Fprint(w, "OK")
+	})
+	mux.HandleFunc("/locales", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":1,"name":"en","is_default":true}]`)
+	})
+	ui := new(mcli.MockUi)
+	c := &PullCommand{UI: ui, Config: new(Config), API: client}
+	c.Run([]string{"--target=./test"})
+
+	if out := ui.OutputWriter.String(); strings.Index(out, "Unchanged") == -1 {
+		t.Errorf("Pull command should report unchanged files, got %q", out)
+	}
+}
+
+func TestPullCommand_incremental(t *testing.T) {
+	setupAPI()
+	defer tearDown()
+
+	os.MkdirAll(testFolder, 0777)
+	config, _ := NewConfig(filepath.Join(testFolder, ".phrase"))
+
+	var updatedSince []string
+	mux.HandleFunc("/translations/download", func(w http.ResponseWriter, r *http.Request) {
+		updatedSince = append(updatedSince, r.URL.Query().Get("updated_since"))
+		w.Header().Add("X-Rate-Limit-Remaining", "59")
+		if len(updatedSince) == 1 {
+			fmt.Fprint(w, "en:\n  hello: Hello\n  title: Title\n")
+		} else {
+			fmt.Fprint(w, "en:\n  hello: Hi\n")
+		}
+	})
+	mux.HandleFunc("/locales", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":1,"name":"en","is_default":true}]`)
+	})
+
+	for i := 0; i < 2; i++ {
+		ui := new(mcli.MockUi)
+		c := &PullCommand{UI: ui, Config: config, API: client}
+		if code := c.Run([]string{"--incremental", "--target=./test"}); code != 0 {
+			t.Fatalf("Pull command should return code == 0, got %s", ui.ErrorWriter.String())
+		}
+	}
+
+	if len(updatedSince) != 2 || updatedSince[0] != "" || updatedSince[1] == "" {
+		t.Errorf("Only the second pull should be limited by updated_since, got %q", updatedSince)
+	}
+	want := "en:\n  hello: Hi\n  title: Title\n"
+	if got, _ := ioutil.ReadFile(filepath.Join(testFolder, "phrase.en.yml")); string(got) != want {
+		t.Errorf("Pull command should merge changes, expected %q, got %q", want, got)
+	}
+	if _, err := os.Stat(filepath.Join(testFolder, ".phrase.state")); err != nil {
+		t.Error("Pull command should save the sync state next to the config file")
+	}
+}
+
+func TestPullCommand_incrementalWithUpdatedSince(t *testing.T) {
+	ui := new(mcli.MockUi)
+	c := &PullCommand{UI: ui, Config: new(Config), API: nil}
+	code := c.Run([]string{"--incremental", "--updated-since=20150401000000"})
+
+	if code == 0 {
+		t.Fatal("Pull command should return code != 0")
+	}
+	if err := ui.ErrorWriter.String(); strings.Index(err, "--incremental cannot be combined") == -1 {
+		t.Fatal("UI should display error message")
+	}
+}
+
+func TestPullCommand_dryRun(t *testing.T) {
+	setupAPI()
+	defer tearDown()
+
+	var counter int32
+	mux.HandleFunc("/translations/download", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&counter, 1)
+	})
+	mux.HandleFunc("/locales", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":1,"name":"en","is_default":true},{"id":2,"name":"ms"}]`)
+	})
+	ui := new(mcli.MockUi)
+	c := &PullCommand{UI: ui, Config: new(Config), API: client}
+	code := c.Run([]string{"--dry-run", "--target=./test"})
+
+	if code != 0 {
+		t.Fatal("Pull command should return code == 0")
+	}
+	if atomic.LoadInt32(&counter) != 0 {
+		t.Errorf("Translations API should not have been called, was called %d times", counter)
+	}
+	want := fmt.Sprintf("Would download locale ms to %s (format: yml)", filepath.Join(testFolder, "phrase.ms.yml"))
+	if out := ui.OutputWriter.String(); strings.Index(out, want) == -1 {
+		t.Errorf("Pull command should display the download plan, got %q", out)
+	}
+	if _, err := os.Stat(testFolder); !os.IsNotExist(err) {
+		t.Error("Pull command should not create folders in a dry run")
+	}
+}
+
+func TestPullCommand_compileMO(t *testing.T) {
+	setupAPI()
+	defer tearDown()
+
+	mux.HandleFunc("/translations/download", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("X-Rate-Limit-Remaining", "59")
+		fmt.Fprint(w, "msgid \"\"\nmsgstr \"Plural-Forms: nplurals=2; plural=(n != 1);\\n\"\n\nmsgid \"file\"\nmsgid_plural \"files\"\nmsgstr[0] \"Datei\"\n")
+	})
+	mux.HandleFunc("/locales", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":1,"name":"de","is_default":true,"pluralizations":{"one":{},"other":{}}}]`)
+	})
+	ui := new(mcli.MockUi)
+	config, _ := NewConfig(filepath.Join(testFolder, ".phrase"))
+	c := &PullCommand{UI: ui, Config: config, API: client}
+	if code := c.Run([]string{"--target=./test", "--format=gettext", "--compile-mo"}); code != 0 {
+		t.Fatalf("Pull command should succeed, got %d: %s", code, ui.ErrorWriter.String())
+	}
+
+	mo := filepath.Join(testFolder, "de", "phrase.mo")
+	if _, err := os.Stat(mo); err != nil {
+		t.Errorf("Pull command should compile %s, got %v", mo, err)
+	}
+	if out := ui.OutputWriter.String(); strings.Index(out, "Compiled "+mo) == -1 {
+		t.Errorf("Pull command should report compiled files, got %q", out)
+	}
+	if out := ui.ErrorWriter.String(); strings.Index(out, "Key file has 1 plural forms, expected 2") == -1 {
+		t.Errorf("Pull command should warn about missing plural forms, got %q", out)
+	}
+}
+
+func TestPullCommand_encoding(t *testing.T) {
+	setupAPI()
+	defer tearDown()
+
+	var encoding string
+	mux.HandleFunc("/translations/download", func(w http.ResponseWriter, r *http.Request) {
+		encoding = r.URL.Query().Get("encoding")
+		w.Header().Add("X-Rate-Limit-Remaining", "59")
+		fmt.Fprint(w, "k=café\n")
+	})
+	mux.HandleFunc("/locales", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":1,"name":"en","is_default":true}]`)
+	})
+	ui := new(mcli.MockUi)
+	config := &Config{Encoding: "UTF-8", Encodings: map[string]string{"properties": "ISO-8859-1"}}
+	c := &PullCommand{UI: ui, Config: config, API: client}
+	if code := c.Run([]string{"--target=./test", "--format=properties"}); code != 0 {
+		t.Fatalf("Pull command should succeed, got %s", ui.ErrorWriter.String())
+	}
+	if encoding != "ISO-8859-1" {
+		t.Errorf("Pull command should request the encoding of the format, got %q", encoding)
+	}
+	want := []byte{'k', '=', 'c', 'a', 'f', 0xe9, '\n'}
+	if got, _ := ioutil.ReadFile(filepath.Join(testFolder, "phrase.en.properties")); !bytes.Equal(got, want) {
+		t.Errorf("Pull command should write files in the configured encoding, expected %v, got %v", want, got)
+	}
+}
+
+func TestPullCommand_targets(t *testing.T) {
+	setupAPI()
+	defer tearDown()
+
+	var mu sync.Mutex
+	var downloads []string
+	mux.HandleFunc("/translations/download", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		downloads = append(downloads, r.FormValue("locale")+":"+r.FormValue("format")+":"+r.FormValue("tag"))
+		mu.Unlock()
+		w.Header().Add("X-Rate-Limit-Remaining", "59")
+		fmt.Fprint(w, "content")
+	})
+	mux.HandleFunc("/locales", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":1,"name":"en","code":"en","is_default":true},{"id":2,"name":"de","code":"de"}]`)
+	})
+	ui := new(mcli.MockUi)
+	config, _ := NewConfig(filepath.Join(testFolder, ".phrase"))
+	config.Targets = []*TargetConfig{
+		{Format: "nested_json", File: filepath.Join(testFolder, "web", "<locale.code>.json"), Tag: "web"},
+		{Format: "strings", File: filepath.Join(testFolder, "ios", "<locale.code>.lproj", "Localizable.strings"), Locales: []string{"de"}},
+	}
+	c := &PullCommand{UI: ui, Config: config, API: client}
+	if code := c.Run([]string{}); code != 0 {
+		t.Fatalf("Pull command should succeed, got %d: %s", code, ui.ErrorWriter.String())
+	}
+	if len(downloads) != 3 {
+		t.Errorf("Pull command should download every locale of every target, got %v", downloads)
+	}
+	for _, path := range []string{filepath.Join("web", "en.json"), filepath.Join("web", "de.json"), filepath.Join("ios", "de.lproj", "Localizable.strings")} {
+		if _, err := os.Stat(filepath.Join(testFolder, path)); err != nil {
+			t.Errorf("Pull command should write %s", path)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(testFolder, "ios", "en.lproj")); !os.IsNotExist(err) {
+		t.Error("Pull command should only download the locales of a target")
+	}
+}
+
+func TestPullCommand_goI18nV2(t *testing.T) {
+	setupAPI()
+	defer tearDown()
+
+	var format string
+	mux.HandleFunc("/translations/download", func(w http.ResponseWriter, r *http.Request) {
+		format = r.FormValue("format")
+		w.Header().Add("X-Rate-Limit-Remaining", "59")
+		fmt.Fprint(w, `{"Cats":{"one":"1 Katze","other":"{{.Count}} Katzen"},"Hello":"Hallo"}`)
+	})
+	mux.HandleFunc("/locales", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":2,"name":"de","code":"de"}]`)
+	})
+	prepareLocaleFiles(map[string][]byte{"active.de.yaml": []byte("Cats:\n  description: Cats\n  other: Katzen\n")}, testFolder)
+
+	ui := new(mcli.MockUi)
+	config := &Config{Targets: []*TargetConfig{{Format: "go_i18n_v2", File: filepath.Join(testFolder, "active.<locale.code>.yaml")}}}
+	c := &PullCommand{UI: ui, Config: config, API: client}
+	if code := c.Run([]string{}); code != 0 {
+		t.Fatalf("Pull command should succeed, got %d: %s", code, ui.ErrorWriter.String())
+	}
+	if format != "nested_json" {
+		t.Errorf("Pull command should download go-i18n files as nested_json, got %q", format)
+	}
+	b, _ := ioutil.ReadFile(filepath.Join(testFolder, "active.de.yaml"))
+	if want := "Cats:\n  description: Cats\n  one: 1 Katze\n  other: '{{.Count}} Katzen'\nHello: Hallo\n"; string(b) != want {
+		t.Errorf("Pull command should convert the translations and keep the descriptions, expected %q, got %q", want, string(b))
+	}
+}
+
+func TestPullCommand_targetsOverriddenByFormat(t *testing.T) {
+	setupAPI()
+	defer tearDown()
+
+	var format string
+	mux.HandleFunc("/translations/download", func(w http.ResponseWriter, r *http.Request) {
+		format = r.FormValue("format")
+		w.Header().Add("X-Rate-Limit-Remaining", "59")
+		fmt.Fprint(w, "content")
+	})
+	mux.HandleFunc("/locales", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":1,"name":"en","is_default":true}]`)
+	})
+	ui := new(mcli.MockUi)
+	config := &Config{Targets: []*TargetConfig{{Format: "nested_json"}}}
+	c := &PullCommand{UI: ui, Config: config, API: client}
+	if code := c.Run([]string{"--target=./test", "--format=yml"}); code != 0 {
+		t.Fatalf("Pull command should succeed, got %d: %s", code, ui.ErrorWriter.String())
+	}
+	if format != "yml" {
+		t.Errorf("Pull command should only download the format given on the command line, got %s", format)
+	}
+}
+
+func TestPullCommand_blacklist(t *testing.T) {
+	setupAPI()
+	defer tearDown()
+
+	mux.HandleFunc("/blacklisted_keys", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"name":"debug.*"}]`)
+	})
+	mux.HandleFunc("/translations/download", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("X-Rate-Limit-Remaining", "59")
+		fmt.Fprint(w, "en:\n  title: Title\n  debug:\n    token: abc\n")
+	})
+	mux.HandleFunc("/locales", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":1,"name":"en","is_default":true}]`)
+	})
+	ui := new(mcli.MockUi)
+	c := &PullCommand{UI: ui, Config: new(Config), API: client}
+	if code := c.Run([]string{"--target=./test"}); code != 0 {
+		t.Fatalf("Pull command should succeed, got %s", ui.ErrorWriter.String())
+	}
+	want := filepath.Join(testFolder, "phrase.en.yml") + " contains 1 blacklisted key(s), which PhraseApp ignores: debug.token"
+	if err := ui.ErrorWriter.String(); strings.Index(err, want) == -1 {
+		t.Errorf("Pull command should warn about blacklisted keys, got %q", err)
+	}
+}
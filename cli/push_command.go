@@ -10,8 +10,8 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
-	"sync"
 )
 
 // PushCommand will upload locale files to PhraseApp.
@@ -19,6 +19,10 @@ type PushCommand struct {
 	UI     mcli.Ui
 	Config *Config
 	API    *phrase.Client
+
+	dryRun bool
+	// blacklist is nil if it could not be fetched.
+	blacklist *blacklist
 }
 
 var defaultLocaleFolder = filepath.Join("config", "locales")
@@ -34,6 +38,7 @@ func (c *PushCommand) Run(args []string) int {
 
 	cmdFlags.StringVar(&config.Secret, "secret", config.Secret, "")
 	cmdFlags.StringVar(&config.Format, "format", config.Format, "")
+	cmdFlags.IntVar(&config.Concurrency, "concurrency", config.Concurrency, "")
 
 	req := new(phrase.UploadRequest)
 	var recursive bool
@@ -45,6 +50,7 @@ func (c *PushCommand) Run(args []string) int {
 	cmdFlags.BoolVar(&req.SkipUnverification, "skip-unverification", false, "")
 	cmdFlags.BoolVar(&req.SkipUploadTags, "skip-upload-tags", false, "")
 	cmdFlags.BoolVar(&req.ConvertEmoji, "convert-emoji", false, "")
+	cmdFlags.BoolVar(&c.dryRun, "dry-run", false, "")
 
 	if err := cmdFlags.Parse(args); err != nil {
 		return 1
@@ -60,9 +66,24 @@ func (c *PushCommand) Run(args []string) int {
 		}
 	}
 
+	if err := config.loadSecret(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
 	c.API.AuthToken = config.Secret
 	req.Format = config.Format
 
+	if len(cmdFlags.Args()) == 0 && len(config.Sources) > 0 {
+		// sources have their own format, unless one is given with --format
+		formatGiven := false
+		cmdFlags.Visit(func(f *flag.Flag) {
+			formatGiven = formatGiven || f.Name == "format"
+		})
+		if !formatGiven {
+			req.Format = ""
+		}
+		return c.uploadSources(req)
+	}
 	return c.upload(req, cmdFlags.Args(), recursive)
 }
 
@@ -79,6 +100,66 @@ func (c *PushCommand) upload(req *phrase.UploadRequest, args []string, recursive
 		return 1
 	}
 
+	pool := newWorkerPool(c.UI, c.Config.Concurrency)
+	c.addUploads(pool, req, selected, nil)
+	c.loadBlacklist()
+	pool.run()
+	if c.dryRun {
+		c.UI.Info("Dry run: no files were uploaded")
+	}
+	return 0
+}
+
+// uploadSources uploads the files of all the configured sources.
+func (c *PushCommand) uploadSources(req *phrase.UploadRequest) int {
+	pool := newWorkerPool(c.UI, c.Config.Concurrency)
+	var matched int
+	for _, source := range c.Config.Sources {
+		if err := source.Valid(); err != nil {
+			c.UI.Error(err.Error())
+			return 1
+		}
+		// files are relative to the config file
+		resolved := *source
+		resolved.Files = c.Config.resolve(source.Files)
+		source = &resolved
+		files, err := source.match()
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error matching %s:\n\t%s", source.Files, err.Error()))
+			return 1
+		}
+		if len(files) == 0 {
+			c.UI.Warn(fmt.Sprintf("No files match %s", source.Files))
+			continue
+		}
+		matched += len(files)
+		sourceReq := source.uploadRequest(*req)
+		c.addUploads(pool, &sourceReq, files, source)
+	}
+	if matched > 1 && req.Locale != "" {
+		c.UI.Error("--locale should not be specified when multiple files are to be uploaded")
+		return 1
+	}
+	c.loadBlacklist()
+	pool.run()
+	if c.dryRun {
+		c.UI.Info("Dry run: no files were uploaded")
+	}
+	return 0
+}
+
+// loadBlacklist fetches the blacklisted keys, which are left out of the
+// uploaded files. Files are uploaded as they are if it cannot.
+func (c *PushCommand) loadBlacklist() {
+	var err error
+	if c.blacklist, err = fetchBlacklist(c.API); err != nil {
+		c.UI.Warn(fmt.Sprintf("Could not fetch the blacklisted keys, uploading files as they are:\n\t%s", err.Error()))
+	}
+}
+
+// addUploads adds a job to upload each of the files to the pool. The
+// locale of files of a source is taken from the source when it tells.
+func (c *PushCommand) addUploads(pool *workerPool, req *phrase.UploadRequest, files []string, source *SourceConfig) {
 	supportedFormats := make(map[string]struct{})
 	for _, format := range formats {
 		for _, ext := range format.properties().extensions {
@@ -86,26 +167,45 @@ func (c *PushCommand) upload(req *phrase.UploadRequest, args []string, recursive
 		}
 	}
 
-	var wg sync.WaitGroup
-	wg.Add(len(selected))
-
-	for _, file := range selected {
-		ext := fileExtension(file)
+	for _, file := range files {
+		f := file
+		fileReq := *req
+		code := false
+		if source != nil && fileReq.Locale == "" {
+			fileReq.Locale, code = source.localeForPath(f)
+		}
+		ext := fileExtension(f)
 		if _, ok := supportedFormats[ext]; ok || rendersLocaleAsExtension(req.Format) {
-			go func(f string) {
-				err := c.uploadFile(req, f)
+			pool.add(func(ui mcli.Ui) {
+				// PhraseApp knows locales by their name
+				if code {
+					var err error
+					if fileReq.Locale, err = localeNameForCode(c.API, fileReq.Locale); err != nil {
+						ui.Error(fmt.Sprintf("Error fetching the locales for %s:\n\t%s", f, err.Error()))
+						return
+					}
+				}
+				if c.dryRun {
+					if err := c.planFile(fileReq, f, ui); err != nil {
+						ui.Error(fmt.Sprintf("Error inspecting %s:\n\t%s", f, err.Error()))
+					}
+					return
+				}
+				err := c.uploadFile(fileReq, f, ui)
 				if err != nil {
-					c.UI.Error(fmt.Sprintf("Error uploading %s:\n\t%s", f, err.Error()))
+					ui.Error(fmt.Sprintf("Error uploading %s:\n\t%s", f, err.Error()))
 				}
-				wg.Done()
-			}(file)
+			})
 		} else {
-			c.UI.Error(fmt.Sprintf("Could not upload %s (type not supported)", file))
-			wg.Done()
+			pool.add(func(ui mcli.Ui) {
+				if c.dryRun {
+					ui.Warn(fmt.Sprintf("Would skip %s (type not supported)", f))
+					return
+				}
+				ui.Error(fmt.Sprintf("Could not upload %s (type not supported)", f))
+			})
 		}
 	}
-	wg.Wait()
-	return 0
 }
 
 func fileExtension(path string) string {
@@ -122,12 +222,39 @@ func rendersLocaleAsExtension(format string) bool {
 	return false
 }
 
-func (c *PushCommand) uploadFile(req *phrase.UploadRequest, file string) error {
+// planFile displays how the file would be uploaded.
+func (c *PushCommand) planFile(req phrase.UploadRequest, file string, ui mcli.Ui) error {
+	format, _, excluded, err := c.readFile(req, file)
+	if err != nil {
+		return err
+	}
+	if req.Locale == "" {
+		req.Locale, err = c.guessLocale(file, req.Format)
+		if err != nil {
+			return err
+		}
+	}
+	var tagged string
+	if len(req.Tags) > 0 {
+		tagged = fmt.Sprintf(", tags: %s", strings.Join(req.Tags, ", "))
+	}
+	if len(excluded) > 0 {
+		tagged += fmt.Sprintf(", %d blacklisted key(s) excluded", len(excluded))
+	}
+	locale := req.Locale
+	if locale == "" {
+		locale = "unknown"
+	}
+	ui.Output(fmt.Sprintf("Would upload %s (locale: %s, format: %s%s)", file, locale, format, tagged))
+	return nil
+}
+
+func (c *PushCommand) uploadFile(req phrase.UploadRequest, file string, ui mcli.Ui) error {
 	var tagged string
 	if len(req.Tags) > 0 {
 		tagged = fmt.Sprintf(" (tagged: %s)", strings.Join(req.Tags, ", "))
 	}
-	c.UI.Output(fmt.Sprintf("Uploading %s%s...", file, tagged))
+	ui.Output(fmt.Sprintf("Uploading %s%s...", file, tagged))
 	if req.Locale == "" {
 		var err error
 		req.Locale, err = c.guessLocale(file, req.Format)
@@ -135,33 +262,70 @@ func (c *PushCommand) uploadFile(req *phrase.UploadRequest, file string) error {
 			return err
 		}
 	}
-	return c.doUpload(*req, file)
+	return c.doUpload(req, file, ui)
 }
 
-func (c *PushCommand) doUpload(req phrase.UploadRequest, file string) error {
-	bytes, err := ioutil.ReadFile(file)
+func (c *PushCommand) doUpload(req phrase.UploadRequest, file string, ui mcli.Ui) error {
+	format, content, excluded, err := c.readFile(req, file)
 	if err != nil {
 		return err
 	}
-	var content string
-	if isUTF16(bytes) {
-		var err error
-		content, err = decodeUTF16(bytes)
-		if err != nil {
+	if len(excluded) > 0 {
+		ui.Output(fmt.Sprintf("Excluded %d blacklisted key(s) from %s", len(excluded), file))
+	}
+	// the API only gets the formats given or the one of the extension
+	if req.Format == "" {
+		req.Format = formatOfExtension(file)
+	}
+	// formats PhraseApp does not know are uploaded converted
+	if f, ok := formats[format]; ok && f.properties().apiFormat != "" {
+		if content, err = f.properties().toAPI(content); err != nil {
 			return err
 		}
-	} else {
-		content = string(bytes)
+		req.Format = f.properties().apiFormat
 	}
 	req.FileContent = content
 	req.Filename = file
 	return c.API.Keys.Upload(&req)
 }
 
+// readFile returns the format of the file and its content to upload,
+// without the blacklisted keys, which it returns as well.
+func (c *PushCommand) readFile(req phrase.UploadRequest, file string) (string, string, []string, error) {
+	format := req.Format
+	if format == "" {
+		format = guessFormatFromFileExtension(file)
+	}
+	bytes, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", "", nil, err
+	}
+	// the API expects UTF-8, whatever the encoding of the file
+	hint, err := encodingHint(c.Config.encodingForFormat(format), format)
+	if err != nil {
+		return "", "", nil, err
+	}
+	content, _, err := decodeText(bytes, hint)
+	if err != nil {
+		return "", "", nil, err
+	}
+	if c.blacklist == nil {
+		return format, content, nil, nil
+	}
+	content, excluded, err := c.blacklist.filter(format, content)
+	return format, content, excluded, err
+}
+
 func (c *PushCommand) guessLocale(file, f string) (string, error) {
 	if f == "" {
 		f = guessFormatFromFileExtension(file)
 	}
+	// the configured layout tells where the locale is, whatever the format
+	if locale, code := c.Config.localeFromPath(file, f); code {
+		return localeNameForCode(c.API, locale)
+	} else if locale != "" {
+		return locale, nil
+	}
 	validFormat, ok := formats[f]
 	if ok && validFormat.properties().localeAware {
 		return validFormat.extractLocaleFromPath(c.API, file)
@@ -169,29 +333,53 @@ func (c *PushCommand) guessLocale(file, f string) (string, error) {
 	return findDefaultLocaleName(c.API)
 }
 
+// formatOfExtension returns the format of the file if it is the only one
+// with its extension, or "" if several formats share it, like json.
+func formatOfExtension(path string) string {
+	extension := fileExtension(path)
+	var found string
+	for name, format := range formats {
+		for _, ext := range format.properties().extensions {
+			if ext == extension {
+				if found != "" {
+					return ""
+				}
+				found = name
+			}
+		}
+	}
+	return found
+}
+
+// guessFormatFromFileExtension returns the format of the file: the format
+// named like its extension, or else the first format by name that has it.
 func guessFormatFromFileExtension(path string) string {
 	extension := fileExtension(path)
 	// if the extension is recognized as a format, use it
 	if _, ok := formats[extension]; ok {
 		return extension
 	}
-	var possibleFormat string
-	for name, format := range formats {
-		for _, ext := range format.properties().extensions {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, ext := range formats[name].properties().extensions {
 			if ext == extension {
-				possibleFormat = name
-				break
+				return name
 			}
 		}
 	}
-	return possibleFormat
+	return ""
 }
 
 func (c *PushCommand) selectFiles(filenames []string, recursive bool) ([]string, error) {
 	if len(filenames) == 0 {
-		if src, err := os.Stat(defaultLocaleFolder); err == nil && src.IsDir() {
-			c.UI.Warn(fmt.Sprintf("No file or directory specified, using %s", defaultLocaleFolder))
-			filenames = append(filenames, defaultLocaleFolder)
+		folder := c.Config.resolve(defaultLocaleFolder)
+		if src, err := os.Stat(folder); err == nil && src.IsDir() {
+			c.UI.Warn(fmt.Sprintf("No file or directory specified, using %s", folder))
+			filenames = append(filenames, folder)
 		} else {
 			c.UI.Error("Need either a file or a directory:")
 			c.UI.Error("go-phrase push FILE")
@@ -242,7 +430,13 @@ func (c *PushCommand) Help() string {
         --skip-unverification           When force updating translations, skip unverification of non-main locale translations
         --skip-upload-tags              Don't create upload tags automatically
         --convert-emoji                 Convert Emojis to store and display them correctlyin PhraseApp
+        --concurrency=2                 Number of files to upload at the same time
+        --dry-run                       Only display which files would be uploaded, without uploading them
         --secret=YOUR_AUTH_TOKEN        The Auth Token to use for this operation instead of the saved one (optional)
+
+	  Without a file or directory, the sources configured in .phrase are uploaded.
+	  Keys blacklisted in PhraseApp are left out of the files of formats go-phrase
+	  can read.
 	`
 	return strings.TrimSpace(helpText)
 }
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 )
@@ -389,3 +390,307 @@ func TestPushCommand_localeSpecifiedMultipleFilesError(t *testing.T) {
 		t.Fatal("UI should display error message")
 	}
 }
+
+func TestPushCommand_concurrency(t *testing.T) {
+	setupAPI()
+	defer tearDown()
+
+	createTestFiles(map[string][]byte{
+		"de.yml": []byte("test"),
+		"fr.yml": []byte("test"),
+		"ru.yml": []byte("test"),
+	})
+
+	var counter int32
+	mux.HandleFunc("/translation_keys/upload", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&counter, 1)
+		fmt.Fprint(w, `{"success":true}`)
+	})
+	mux.HandleFunc("/locales", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":1,"name":"en","is_default":true}]`)
+	})
+
+	ui := new(mcli.MockUi)
+	c := &PushCommand{UI: ui, Config: new(Config), API: client}
+	code := c.Run([]string{"--concurrency=1", "--format=yml", testFolder})
+
+	if code != 0 {
+		t.Fatal("Push command should return code == 0")
+	}
+	if got := c.Config.Concurrency; got != 1 {
+		t.Errorf("Config concurrency should be set to 1, was %d", got)
+	}
+	if atomic.LoadInt32(&counter) != 3 {
+		t.Errorf("Translations API should have been called 3 times, was called %d times", counter)
+	}
+	want := fmt.Sprintf("Uploading %s...\nUploading %s...\nUploading %s...\n",
+		filepath.Join(testFolder, "de.yml"), filepath.Join(testFolder, "fr.yml"), filepath.Join(testFolder, "ru.yml"))
+	if got := ui.OutputWriter.String(); got != want {
+		t.Errorf("Push command output expected %q, got %q", want, got)
+	}
+}
+
+func TestPushCommand_dryRun(t *testing.T) {
+	setupAPI()
+	defer tearDown()
+
+	createTestFiles(map[string][]byte{
+		"en.ini": []byte("test"),
+		"en.xxx": []byte("test"),
+	})
+
+	var counter int32
+	mux.HandleFunc("/translation_keys/upload", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&counter, 1)
+		fmt.Fprint(w, `{"success":true}`)
+	})
+	mux.HandleFunc("/locales", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":1,"name":"en","is_default":true}]`)
+	})
+
+	ui := new(mcli.MockUi)
+	c := &PushCommand{UI: ui, Config: new(Config), API: client}
+	code := c.Run([]string{"--dry-run", "--tags=web", testFolder})
+
+	if code != 0 {
+		t.Fatal("Push command should return code == 0")
+	}
+	if atomic.LoadInt32(&counter) != 0 {
+		t.Errorf("Translations API should not have been called, was called %d times", counter)
+	}
+	want := fmt.Sprintf("Would upload %s (locale: en, format: ini, tags: web)", filepath.Join(testFolder, "en.ini"))
+	if out := ui.OutputWriter.String(); strings.Index(out, want) == -1 {
+		t.Errorf("Push command should display the upload plan, got %q", out)
+	}
+	if err := ui.ErrorWriter.String(); strings.Index(err, "Would skip") == -1 {
+		t.Errorf("Push command should display unsupported files, got %q", err)
+	}
+}
+
+func TestPushCommand_latin1(t *testing.T) {
+	setupAPI()
+	defer tearDown()
+
+	createTestFiles(map[string][]byte{
+		"en.properties": []byte{'k', '=', 'c', 'a', 'f', 0xe9},
+	})
+
+	var content string
+	mux.HandleFunc("/translation_keys/upload", func(w http.ResponseWriter, r *http.Request) {
+		content = r.FormValue("file_content")
+		fmt.Fprint(w, `{"success":true}`)
+	})
+
+	ui := new(mcli.MockUi)
+	c := &PushCommand{UI: ui, Config: new(Config), API: client}
+	if code := c.Run([]string{testFolder}); code != 0 {
+		t.Fatalf("Push command should return code == 0, got %s", ui.ErrorWriter.String())
+	}
+	if content != "k=café" {
+		t.Errorf("Push command should upload Latin-1 files as UTF-8, got %q", content)
+	}
+}
+
+func TestPushCommand_localeFromConfiguredPath(t *testing.T) {
+	setupAPI()
+	defer tearDown()
+
+	prepareLocaleFiles(map[string][]byte{"messages.json": []byte(`{"hello":"Bonjour"}`)}, testFolder, "fr")
+
+	var locale string
+	mux.HandleFunc("/translation_keys/upload", func(w http.ResponseWriter, r *http.Request) {
+		locale = r.FormValue("locale_name")
+		fmt.Fprint(w, `{"success":true}`)
+	})
+	mux.HandleFunc("/locales", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":1,"name":"en","is_default":true}]`)
+	})
+
+	ui := new(mcli.MockUi)
+	config := &Config{LocaleDirectory: "<locale.name>", LocaleFilename: "messages.json"}
+	c := &PushCommand{UI: ui, Config: config, API: client}
+	if code := c.Run([]string{"--recursive", testFolder}); code != 0 {
+		t.Fatalf("Push command should return code == 0, got %s", ui.ErrorWriter.String())
+	}
+	if locale != "fr" {
+		t.Errorf("Push command should take the locale from the configured path, got %q", locale)
+	}
+}
+
+func TestPushCommand_localeCodeFromConfiguredPath(t *testing.T) {
+	setupAPI()
+	defer tearDown()
+
+	prepareLocaleFiles(map[string][]byte{"messages.json": []byte(`{"hello":"Bonjour"}`)}, testFolder, "fr-FR")
+
+	var locale string
+	mux.HandleFunc("/translation_keys/upload", func(w http.ResponseWriter, r *http.Request) {
+		locale = r.FormValue("locale_name")
+		fmt.Fprint(w, `{"success":true}`)
+	})
+	mux.HandleFunc("/locales", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":1,"name":"English","code":"en-US","is_default":true},{"id":2,"name":"French","code":"fr-FR"}]`)
+	})
+
+	ui := new(mcli.MockUi)
+	config := &Config{LocaleDirectory: "<locale.code>", LocaleFilename: "messages.json"}
+	c := &PushCommand{UI: ui, Config: config, API: client}
+	if code := c.Run([]string{"--recursive", testFolder}); code != 0 {
+		t.Fatalf("Push command should return code == 0, got %s", ui.ErrorWriter.String())
+	}
+	if locale != "French" {
+		t.Errorf("Push command should upload to the locale with the code in the path, got %q", locale)
+	}
+}
+
+func TestPushCommand_sources(t *testing.T) {
+	setupAPI()
+	defer tearDown()
+
+	prepareLocaleFiles(map[string][]byte{"de.json": []byte(`{"hello":"Hallo"}`), "fr.json": []byte(`{"hello":"Bonjour"}`)}, testFolder, "web")
+	prepareLocaleFiles(map[string][]byte{"strings.xml": []byte(`<resources/>`)}, testFolder, "android", "values-de")
+
+	var mu sync.Mutex
+	uploads := make(map[string]string)
+	mux.HandleFunc("/translation_keys/upload", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		uploads[r.FormValue("locale_name")+":"+r.FormValue("file_format")] = r.FormValue("tags[]")
+		mu.Unlock()
+		fmt.Fprint(w, `{"success":true}`)
+	})
+	mux.HandleFunc("/locales", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":1,"name":"German","code":"de"}]`)
+	})
+
+	ui := new(mcli.MockUi)
+	config := &Config{Sources: []*SourceConfig{
+		{Files: filepath.Join(testFolder, "web", "<locale.name>.json"), Format: "nested_json", Tags: []string{"web"}},
+		{Files: filepath.Join(testFolder, "android", "values-<locale.code>", "strings.xml"), Format: "xml"},
+		{Files: filepath.Join(testFolder, "ios", "*.strings")},
+	}}
+	c := &PushCommand{UI: ui, Config: config, API: client}
+	if code := c.Run([]string{}); code != 0 {
+		t.Fatalf("Push command should return code == 0, got %s", ui.ErrorWriter.String())
+	}
+	if len(uploads) != 3 || uploads["de:nested_json"] != "web" || uploads["fr:nested_json"] != "web" {
+		t.Errorf("Push command should upload the files of all sources, got %v", uploads)
+	}
+	if _, ok := uploads["German:xml"]; !ok {
+		t.Errorf("Push command should take the locale of Android files from the code in their path, got %v", uploads)
+	}
+	if err := ui.ErrorWriter.String(); strings.Index(err, "No files match") == -1 {
+		t.Errorf("Push command should warn about sources without files, got %q", err)
+	}
+}
+
+func TestPushCommand_sourcesFormat(t *testing.T) {
+	setupAPI()
+	defer tearDown()
+
+	prepareLocaleFiles(map[string][]byte{"de.strings": []byte(`"hello" = "Hallo";`)}, testFolder, "ios")
+	prepareLocaleFiles(map[string][]byte{"de.json": []byte(`{"hello":"Hallo"}`)}, testFolder, "web")
+
+	var mu sync.Mutex
+	formats := make(map[string]string)
+	mux.HandleFunc("/translation_keys/upload", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		formats[filepath.Base(r.FormValue("filename"))] = r.FormValue("file_format")
+		mu.Unlock()
+		fmt.Fprint(w, `{"success":true}`)
+	})
+
+	sources := []*SourceConfig{
+		{Files: filepath.Join(testFolder, "ios", "<locale.name>.strings")},
+		{Files: filepath.Join(testFolder, "web", "<locale.name>.json"), Format: "nested_json"},
+	}
+	ui := new(mcli.MockUi)
+	c := &PushCommand{UI: ui, Config: &Config{Format: "yml", Sources: sources}, API: client}
+	if code := c.Run([]string{}); code != 0 {
+		t.Fatalf("Push command should return code == 0, got %s", ui.ErrorWriter.String())
+	}
+	if formats["de.strings"] != "strings" || formats["de.json"] != "nested_json" {
+		t.Errorf("Push command should use the format of sources or of the extension of their files, got %v", formats)
+	}
+
+	ui = new(mcli.MockUi)
+	c = &PushCommand{UI: ui, Config: &Config{Sources: sources}, API: client}
+	if code := c.Run([]string{"--format=simple_json"}); code != 0 {
+		t.Fatalf("Push command should return code == 0, got %s", ui.ErrorWriter.String())
+	}
+	if formats["de.strings"] != "simple_json" || formats["de.json"] != "simple_json" {
+		t.Errorf("Push command should use the format given with --format, got %v", formats)
+	}
+
+	ui = new(mcli.MockUi)
+	c = &PushCommand{UI: ui, Config: &Config{Sources: sources}, API: client}
+	if code := c.Run([]string{"--locale=de"}); code == 0 {
+		t.Fatal("Push command should fail with --locale for several files")
+	}
+	if err := ui.ErrorWriter.String(); !strings.Contains(err, "--locale should not be specified") {
+		t.Errorf("Push command should explain why it failed, got %q", err)
+	}
+}
+
+func TestPushCommand_goI18nV2(t *testing.T) {
+	setupAPI()
+	defer tearDown()
+
+	createTestFiles(map[string][]byte{
+		"active.de.toml": []byte("[Cats]\ndescription = \"Cats\"\none = \"1 Katze\"\nother = \"{{.Count}} Katzen\"\n"),
+	})
+
+	var format, content, locale string
+	mux.HandleFunc("/translation_keys/upload", func(w http.ResponseWriter, r *http.Request) {
+		format, content, locale = r.FormValue("file_format"), r.FormValue("file_content"), r.FormValue("locale_name")
+		fmt.Fprint(w, `{"success":true}`)
+	})
+
+	ui := new(mcli.MockUi)
+	c := &PushCommand{UI: ui, Config: new(Config), API: client}
+	if code := c.Run([]string{"--format=go_i18n_v2", testFolder}); code != 0 {
+		t.Fatalf("Push command should return code == 0, got %s", ui.ErrorWriter.String())
+	}
+	if format != "nested_json" || locale != "de" {
+		t.Errorf("Push command should upload go-i18n files as nested_json, got format %q and locale %q", format, locale)
+	}
+	if want := "{\n  \"Cats\": {\n    \"one\": \"1 Katze\",\n    \"other\": \"{{.Count}} Katzen\"\n  }\n}\n"; content != want {
+		t.Errorf("Push command should convert go-i18n files, expected %q, got %q", want, content)
+	}
+}
+
+func TestFormatOfExtension(t *testing.T) {
+	for path, want := range map[string]string{"de.strings": "strings", "de.json": "", "de.po": "gettext", "de.unknown": ""} {
+		if got := formatOfExtension(path); got != want {
+			t.Errorf("formatOfExtension(%q) returned %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestPushCommand_blacklist(t *testing.T) {
+	setupAPI()
+	defer tearDown()
+
+	createTestFiles(map[string][]byte{
+		"en.yml": []byte("en:\n  title: Title\n  debug:\n    token: abc\n"),
+	})
+	mux.HandleFunc("/blacklisted_keys", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"name":"debug.*"}]`)
+	})
+	var content string
+	mux.HandleFunc("/translation_keys/upload", func(w http.ResponseWriter, r *http.Request) {
+		content = r.FormValue("file_content")
+		fmt.Fprint(w, `{"success":true}`)
+	})
+
+	ui := new(mcli.MockUi)
+	c := &PushCommand{UI: ui, Config: new(Config), API: client}
+	if code := c.Run([]string{"--locale=en", filepath.Join(testFolder, "en.yml")}); code != 0 {
+		t.Fatalf("Push command should return code == 0, got %s", ui.ErrorWriter.String())
+	}
+	if want := "en:\n  title: Title\n"; content != want {
+		t.Errorf("Push command should upload files without blacklisted keys, expected %q, got %q", want, content)
+	}
+	if out := ui.OutputWriter.String(); strings.Index(out, "Excluded 1 blacklisted key(s) from "+filepath.Join(testFolder, "en.yml")) == -1 {
+		t.Errorf("Push command should report the excluded keys, got %q", out)
+	}
+}
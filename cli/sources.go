@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"github.com/weynsee/go-phrase/phrase"
+	"path/filepath"
+)
+
+// SourceConfig describes a set of locale files uploaded by push, e.g. the
+// files of one of the apps of a monorepo.
+type SourceConfig struct {
+	// Files to upload, as a glob pattern like web/locales/*.json. Allows the placeholders <locale.name>, <locale.code> and <domain>, which match like * and tell the locale of a file, e.g. web/locales/<locale.code>.json.
+	Files string `json:"files"`
+	// Format of the files, unless push is given --format. Guessed from the extension of each file if empty, whatever the top-level format.
+	Format string `json:"format,omitempty"`
+	// Tags to add to the uploaded keys.
+	Tags []string `json:"tags,omitempty"`
+	// Locale of all the files, for formats that do not include the locale in the file content or path.
+	Locale string `json:"locale,omitempty"`
+	// Maps locales found in file paths to the names of PhraseApp locales, e.g. {"en_US": "en-US"}.
+	LocaleMapping map[string]string `json:"locale_mapping,omitempty"`
+	// Force update of existing translations with the file content.
+	UpdateTranslations bool `json:"update_translations,omitempty"`
+	// When force updating translations, skip unverification of non-main locale translations.
+	SkipUnverification bool `json:"skip_unverification,omitempty"`
+	// Don't create upload tags automatically.
+	SkipUploadTags bool `json:"skip_upload_tags,omitempty"`
+	// Convert Emoji symbols.
+	ConvertEmoji bool `json:"convert_emoji,omitempty"`
+}
+
+// TargetConfig describes a set of locale files downloaded by pull.
+type TargetConfig struct {
+	// Format of the files to download.
+	Format string `json:"format"`
+	// Path of the file of a locale, e.g. ios/<locale.code>.lproj/Localizable.strings. Allows placeholders. Defaults to the path of the format in its default target directory.
+	File string `json:"file,omitempty"`
+	// Names of the locales to download. All locales are downloaded if empty.
+	Locales []string `json:"locales,omitempty"`
+	// Limit results to a given tag instead of all translations.
+	Tag string `json:"tag,omitempty"`
+	// Encoding of the files, overriding the configured encodings.
+	Encoding string `json:"encoding,omitempty"`
+	// Include empty translations in the result.
+	IncludeEmptyTranslations bool `json:"include_empty_translations,omitempty"`
+	// Skip unverified translations in the result.
+	SkipUnverifiedTranslations bool `json:"skip_unverified_translations,omitempty"`
+	// Convert Emoji symbols.
+	ConvertEmoji bool `json:"convert_emoji,omitempty"`
+}
+
+// Valid validates the source.
+func (s *SourceConfig) Valid() error {
+	if s.Files == "" {
+		return errors.New("Sources must have files to upload")
+	}
+	if _, err := filepath.Match(s.glob(), ""); err != nil {
+		return fmt.Errorf("Invalid files pattern %s: %s", s.Files, err.Error())
+	}
+	if _, found := formats[s.Format]; s.Format != "" && !found {
+		return errors.New("Unrecognized format: " + s.Format)
+	}
+	for _, tag := range s.Tags {
+		if !validTag.MatchString(tag) {
+			return fmt.Errorf("Tag %s is invalid: Only letters, numbers, underscores and dashes are allowed", tag)
+		}
+	}
+	return nil
+}
+
+// glob returns the pattern of the files, with placeholders matching
+// like *.
+func (s *SourceConfig) glob() string {
+	return placeholderFormat.ReplaceAllString(s.Files, "*")
+}
+
+// match returns the files of the source.
+func (s *SourceConfig) match() ([]string, error) {
+	return filepath.Glob(s.glob())
+}
+
+// localeForPath returns the locale of a file of the source, either the
+// locale of the source or the locale in the path, or "" if neither tells.
+// It returns true if the locale is the code matched by <locale.code>.
+func (s *SourceConfig) localeForPath(path string) (string, bool) {
+	if s.Locale != "" {
+		return s.Locale, false
+	}
+	directory, filename := filepath.Split(s.Files)
+	locale, code := localeFromPlaceholders(matchPlaceholders(directory, filename, path, nil))
+	if mapped, ok := s.LocaleMapping[locale]; ok {
+		return mapped, false
+	}
+	return locale, code
+}
+
+// uploadRequest returns req with the options of the source. The format of
+// req, if any, wins over the one of the source. Without either, the format
+// is guessed for each file.
+func (s *SourceConfig) uploadRequest(req phrase.UploadRequest) phrase.UploadRequest {
+	if req.Format == "" {
+		req.Format = s.Format
+	}
+	req.Tags = append(append([]string{}, req.Tags...), s.Tags...)
+	req.UpdateTranslations = req.UpdateTranslations || s.UpdateTranslations
+	req.SkipUnverification = req.SkipUnverification || s.SkipUnverification
+	req.SkipUploadTags = req.SkipUploadTags || s.SkipUploadTags
+	req.ConvertEmoji = req.ConvertEmoji || s.ConvertEmoji
+	return req
+}
+
+// Valid validates the target.
+func (t *TargetConfig) Valid() error {
+	if _, found := formats[t.Format]; !found {
+		return errors.New("Unrecognized format: " + t.Format)
+	}
+	_, err := parseEncoding(t.Encoding)
+	return err
+}
+
+// downloadRequest returns req with the options of the target.
+func (t *TargetConfig) downloadRequest(req phrase.DownloadRequest) phrase.DownloadRequest {
+	if t.Tag != "" {
+		req.Tag = t.Tag
+	}
+	req.IncludeEmptyTranslations = req.IncludeEmptyTranslations || t.IncludeEmptyTranslations
+	req.SkipUnverifiedTranslations = req.SkipUnverifiedTranslations || t.SkipUnverifiedTranslations
+	req.ConvertEmoji = req.ConvertEmoji || t.ConvertEmoji
+	return req
+}
+
+// forTarget returns the config to pull a target with. Targets without a
+// file are written to the paths of their format.
+func (c *Config) forTarget(t *TargetConfig) *Config {
+	tc := *c
+	tc.Format = t.Format
+	tc.TargetDirectory, tc.LocaleDirectory, tc.LocaleFilename = "", "", ""
+	if t.File != "" {
+		tc.TargetDirectory = "./"
+		tc.LocaleDirectory, tc.LocaleFilename = filepath.Split(t.File)
+		if tc.LocaleDirectory == "" {
+			tc.LocaleDirectory = "./"
+		}
+	}
+	if t.Encoding != "" {
+		tc.Encoding, tc.Encodings = t.Encoding, nil
+	}
+	tc.Sources, tc.Targets = nil, nil
+	return &tc
+}
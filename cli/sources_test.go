@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"github.com/weynsee/go-phrase/phrase"
+	"path/filepath"
+	"testing"
+)
+
+func TestSourceConfig_Valid(t *testing.T) {
+	valid := []*SourceConfig{
+		{Files: "web/locales/*.json"},
+		{Files: "web/locales/<locale.code>.json", Format: "nested_json", Tags: []string{"web"}},
+	}
+	for _, s := range valid {
+		if err := s.Valid(); err != nil {
+			t.Errorf("Source %+v should be valid, got %v", s, err)
+		}
+	}
+	invalid := []*SourceConfig{
+		{},
+		{Files: "web/[", Format: "nested_json"},
+		{Files: "*.json", Format: "unknown"},
+		{Files: "*.json", Tags: []string{"no spaces"}},
+	}
+	for _, s := range invalid {
+		if err := s.Valid(); err == nil {
+			t.Errorf("Source %+v should be invalid", s)
+		}
+	}
+}
+
+func TestSourceConfig_localeForPath(t *testing.T) {
+	s := &SourceConfig{Files: "android/*/values-<locale.code>/strings.xml", LocaleMapping: map[string]string{"pt-rBR": "pt-BR"}}
+	tests := map[string]string{
+		"android/app/values-de/strings.xml":     "de",
+		"android/app/values-pt-rBR/strings.xml": "pt-BR",
+		"android/app/values/strings.xml":        "",
+	}
+	for path, want := range tests {
+		got, code := s.localeForPath(filepath.FromSlash(path))
+		if got != want {
+			t.Errorf("localeForPath(%s) expected %q, got %q", path, want, got)
+		}
+		if code != (want == "de") {
+			t.Errorf("localeForPath(%s) should only return true for codes that are not mapped", path)
+		}
+	}
+	s = &SourceConfig{Files: "ios/Base.lproj/*.strings", Locale: "en"}
+	if got, _ := s.localeForPath("ios/Base.lproj/Localizable.strings"); got != "en" {
+		t.Errorf("localeForPath should return the locale of the source, got %q", got)
+	}
+}
+
+func TestSourceConfig_uploadRequest(t *testing.T) {
+	s := &SourceConfig{Format: "xml", Tags: []string{"android"}, UpdateTranslations: true}
+	req := s.uploadRequest(phrase.UploadRequest{Tags: []string{"release"}, ConvertEmoji: true})
+	if req.Format != "xml" || len(req.Tags) != 2 || !req.UpdateTranslations || !req.ConvertEmoji {
+		t.Errorf("uploadRequest returned unexpected request %+v", req)
+	}
+	if req := s.uploadRequest(phrase.UploadRequest{Format: "yml"}); req.Format != "yml" {
+		t.Errorf("The format of the request should win over the one of the source, got %s", req.Format)
+	}
+}
+
+func TestTargetConfig_downloadRequest(t *testing.T) {
+	target := &TargetConfig{Format: "strings", Tag: "ios", SkipUnverifiedTranslations: true}
+	req := target.downloadRequest(phrase.DownloadRequest{Tag: "all", ConvertEmoji: true})
+	if req.Tag != "ios" || !req.SkipUnverifiedTranslations || !req.ConvertEmoji {
+		t.Errorf("downloadRequest returned unexpected request %+v", req)
+	}
+	if err := (&TargetConfig{Format: "unknown"}).Valid(); err == nil {
+		t.Error("Targets with unknown formats should be invalid")
+	}
+	if err := (&TargetConfig{Format: "strings", Encoding: "EBCDIC"}).Valid(); err == nil {
+		t.Error("Targets with unsupported encodings should be invalid")
+	}
+}
+
+func TestConfig_forTarget(t *testing.T) {
+	c := &Config{Format: "yml", TargetDirectory: "config/locales", LocaleFilename: "<locale.name>.yml", Targets: []*TargetConfig{{Format: "xml"}}}
+	lc := c.forTarget(&TargetConfig{Format: "nested_json", File: "web/i18n/<locale.code>.json"}).ForLocale(&phrase.Locale{Name: "German", Code: "de"})
+	if _, path := localePath(lc); path != filepath.Join("web", "i18n", "de.json") {
+		t.Errorf("Targets with a file should be written to it, got %s", path)
+	}
+	lc = c.forTarget(&TargetConfig{Format: "xml"}).ForLocale(&phrase.Locale{Name: "German", Code: "de"})
+	if _, path := localePath(lc); path != filepath.Join("res", "values-de", "strings.xml") {
+		t.Errorf("Targets without a file should be written to the path of their format, got %s", path)
+	}
+}
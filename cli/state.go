@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const stateFilename = ".phrase.state"
+
+// syncState stores the time of the last successful pull for every
+// combination of locale, format, tag and local file. It is persisted as JSON next to
+// the .phrase config file, and is safe for concurrent use.
+type syncState struct {
+	path string
+	mu   sync.Mutex
+
+	Pulls map[string]time.Time `json:"pulls"`
+}
+
+// loadSyncState returns the syncState stored in path. A missing file
+// results in an empty state.
+func loadSyncState(path string) (*syncState, error) {
+	state := &syncState{path: path, Pulls: make(map[string]time.Time)}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err = json.NewDecoder(f).Decode(state); err != nil {
+		return nil, err
+	}
+	if state.Pulls == nil {
+		state.Pulls = make(map[string]time.Time)
+	}
+	return state, nil
+}
+
+// stateKey returns the key of a pull into the file at path, which is
+// relative to the state file when possible, so that targets sharing a
+// format and a tag do not share their time of last pull.
+func (s *syncState) stateKey(locale, format, tag, path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		if dir, err := filepath.Abs(filepath.Dir(s.path)); err == nil {
+			if rel, err := filepath.Rel(dir, abs); err == nil {
+				path = rel
+			}
+		}
+	}
+	return locale + "/" + format + "/" + tag + "/" + filepath.ToSlash(path)
+}
+
+// lastPull returns the time of the last successful pull into the file at
+// path, or the zero time if there was none.
+func (s *syncState) lastPull(locale, format, tag, path string) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Pulls[s.stateKey(locale, format, tag, path)]
+}
+
+// recordPull stores t as the time of the last successful pull into the
+// file at path.
+func (s *syncState) recordPull(locale, format, tag, path string, t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Pulls[s.stateKey(locale, format, tag, path)] = t.UTC()
+}
+
+// save writes the state to disk.
+func (s *syncState) save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bytes, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = writeFileAtomic(s.path, append(bytes, '\n'))
+	return err
+}
+
+// statePath returns the path of the sync state file, which is stored
+// in the same directory as the config file.
+func (c *Config) statePath() string {
+	return filepath.Join(filepath.Dir(c.path), stateFilename)
+}
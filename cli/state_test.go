@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadSyncState_missing(t *testing.T) {
+	state, err := loadSyncState("state_that_does_not_exist")
+	if err != nil {
+		t.Fatalf("loadSyncState returned error %v", err)
+	}
+	if got := state.lastPull("en", "yml", "", "en.yml"); !got.IsZero() {
+		t.Errorf("lastPull should return the zero time, got %v", got)
+	}
+}
+
+func TestLoadSyncState_error(t *testing.T) {
+	defer os.RemoveAll(testFolder)
+	prepareLocaleFiles(map[string][]byte{"invalid.state": []byte(`false`)}, testFolder)
+	path := filepath.Join(testFolder, "invalid.state")
+
+	if _, err := loadSyncState(path); err == nil {
+		t.Error("loadSyncState should return an error for invalid JSON")
+	}
+}
+
+func TestSyncState_save(t *testing.T) {
+	os.MkdirAll(testFolder, 0777)
+	defer os.RemoveAll(testFolder)
+	path := filepath.Join(testFolder, "test.state")
+
+	state, _ := loadSyncState(path)
+	now := time.Date(2015, 4, 1, 10, 0, 0, 0, time.UTC)
+	state.recordPull("en", "yml", "web", filepath.Join(testFolder, "web", "en.yml"), now)
+	if err := state.save(); err != nil {
+		t.Fatalf("save returned error %v", err)
+	}
+
+	loaded, err := loadSyncState(path)
+	if err != nil {
+		t.Fatalf("loadSyncState returned error %v", err)
+	}
+	if got := loaded.lastPull("en", "yml", "web", filepath.Join(testFolder, "web", "en.yml")); !got.Equal(now) {
+		t.Errorf("lastPull expected %v, got %v", now, got)
+	}
+	if got := loaded.lastPull("en", "yml", "", filepath.Join(testFolder, "web", "en.yml")); !got.IsZero() {
+		t.Errorf("lastPull for another tag should be the zero time, got %v", got)
+	}
+	if got := loaded.lastPull("en", "yml", "web", filepath.Join(testFolder, "app", "en.yml")); !got.IsZero() {
+		t.Errorf("lastPull for another file should be the zero time, got %v", got)
+	}
+}
+
+func TestConfig_statePath(t *testing.T) {
+	c, _ := NewConfig(filepath.Join("project", ".phrase"))
+	if want, got := filepath.Join("project", ".phrase.state"), c.statePath(); got != want {
+		t.Errorf("statePath expected %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,270 @@
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	mcli "github.com/mitchellh/cli"
+	"github.com/weynsee/go-phrase/phrase"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// StatusCommand will compare the local locale files with the translations in PhraseApp.
+type StatusCommand struct {
+	UI     mcli.Ui
+	Config *Config
+	API    *phrase.Client
+}
+
+// Run executes the status command.
+func (c *StatusCommand) Run(args []string) int {
+	cmdFlags := flag.NewFlagSet("status", flag.ContinueOnError)
+	cmdFlags.Usage = func() { c.UI.Output(c.Help()) }
+
+	config := c.Config
+
+	cmdFlags.StringVar(&config.Secret, "secret", config.Secret, "")
+	cmdFlags.StringVar(&config.TargetDirectory, "target", config.TargetDirectory, "")
+	cmdFlags.StringVar(&config.Encoding, "encoding", config.Encoding, "")
+	cmdFlags.StringVar(&config.Format, "format", config.Format, "")
+	cmdFlags.IntVar(&config.Concurrency, "concurrency", config.Concurrency, "")
+
+	req := new(phrase.DownloadRequest)
+	cmdFlags.StringVar(&req.Tag, "tag", "", "")
+	cmdFlags.BoolVar(&req.SkipUnverifiedTranslations, "skip-unverified-translations", false, "")
+	cmdFlags.BoolVar(&req.IncludeEmptyTranslations, "include-empty-translations", false, "")
+
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	if config.Format == "" {
+		config.Format = defaultDownloadFormat
+	}
+
+	if err := config.loadSecret(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	c.API.AuthToken = config.Secret
+	req.Encoding = config.encodingForFormat(config.Format)
+	req.Format = config.Format
+
+	if err := config.Valid(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	// the configured targets are compared unless the command line asks for
+	// a single format or folder, like pull
+	explicit := false
+	cmdFlags.Visit(func(f *flag.Flag) {
+		explicit = explicit || f.Name == "format" || f.Name == "target"
+		if f.Name == "target" {
+			config.root = ""
+		}
+	})
+	if len(config.Targets) > 0 && !explicit {
+		status := 0
+		for _, target := range config.Targets {
+			targetConfig := config.forTarget(target)
+			targetReq := target.downloadRequest(*req)
+			targetReq.Format = targetConfig.Format
+			targetReq.Encoding = targetConfig.encodingForFormat(targetConfig.Format)
+			locales := cmdFlags.Args()
+			if len(locales) == 0 {
+				locales = target.Locales
+			}
+			if !c.compare(targetConfig, targetReq, locales) {
+				status = 1
+			}
+		}
+		return status
+	}
+
+	if !c.compare(config, *req, cmdFlags.Args()) {
+		return 1
+	}
+	return 0
+}
+
+// compare compares the local files of the locales of a config with their
+// translations in PhraseApp, all locales if none are given. It returns
+// false if any differs, if they could not be compared, or if any of the
+// given locales is unknown.
+func (c *StatusCommand) compare(config *Config, req phrase.DownloadRequest, locales []string) bool {
+	selected, err := selectLocales(c.API, c.UI, locales)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error encountered fetching the locales:\n\t%s", err.Error()))
+		return false
+	}
+
+	var failed int32
+	if len(selected) < len(locales) {
+		failed = 1
+	}
+	pool := newWorkerPool(c.UI, config.Concurrency)
+	for _, locale := range selected {
+		l := locale
+		pool.add(func(ui mcli.Ui) {
+			if !c.compareLocale(config, req, l, ui) {
+				atomic.StoreInt32(&failed, 1)
+			}
+		})
+	}
+	pool.run()
+	return atomic.LoadInt32(&failed) == 0
+}
+
+// compareLocale displays the differences between the local file of a locale
+// and its translations in PhraseApp. It returns false if there are
+// differences, or if they could not be determined.
+func (c *StatusCommand) compareLocale(config *Config, req phrase.DownloadRequest, locale phrase.Locale, ui mcli.Ui) bool {
+	lc := config.ForLocale(&locale)
+	_, path := localePath(lc)
+
+	req.Locale = locale.Name
+	download := req
+	if format := lc.properties().apiFormat; format != "" {
+		download.Format = format
+	}
+	var remote bytes.Buffer
+	limit, err := c.API.Translations.Download(&download, &remote)
+	if err != nil {
+		ui.Error(fmt.Sprintf("Error downloading locale %s:\n\t%s", req.Locale, err.Error()))
+		return false
+	}
+	if limit.Remaining == 0 {
+		ui.Error(fmt.Sprintf("Rate limit reached. Please try again at %v", limit.Reset))
+		return false
+	}
+
+	local, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		ui.Warn(fmt.Sprintf("%s: %s is missing", locale.Name, path))
+		return false
+	} else if err != nil {
+		ui.Error(fmt.Sprintf("Error reading file %s:\n\t%s", path, err.Error()))
+		return false
+	}
+
+	codec := lc.properties().codec
+	if codec == nil {
+		normalized, err := config.normalizeEncoding(req.Format, remote.Bytes())
+		if err != nil {
+			ui.Error(fmt.Sprintf("Error encoding locale %s:\n\t%s", locale.Name, err.Error()))
+			return false
+		}
+		if bytes.Equal(local, normalized) {
+			ui.Output(fmt.Sprintf("%s: %s is up to date", locale.Name, path))
+			return true
+		}
+		ui.Warn(fmt.Sprintf("%s: %s differs from PhraseApp", locale.Name, path))
+		return false
+	}
+
+	hint, err := encodingHint(req.Encoding, req.Format)
+	if err != nil {
+		ui.Error(err.Error())
+		return false
+	}
+	localCatalog, err := decodeFile(codec, local, hint)
+	if err != nil {
+		ui.Error(fmt.Sprintf("Error parsing file %s:\n\t%s", path, err.Error()))
+		return false
+	}
+	remoteCodec := codec
+	if format := lc.properties().apiFormat; format != "" {
+		remoteCodec = formats[format].properties().codec
+	}
+	remoteCatalog, err := decodeFile(remoteCodec, remote.Bytes(), hint)
+	if err != nil {
+		ui.Error(fmt.Sprintf("Error parsing locale %s from PhraseApp:\n\t%s", locale.Name, err.Error()))
+		return false
+	}
+
+	diff := diffKeys(localCatalog.flatten(), remoteCatalog.flatten())
+	if diff.empty() {
+		ui.Output(fmt.Sprintf("%s: %s is up to date", locale.Name, path))
+		return true
+	}
+	ui.Warn(fmt.Sprintf("%s: %s has %d added, %d removed and %d changed keys", locale.Name, path,
+		len(diff.added), len(diff.removed), len(diff.changed)))
+	for _, key := range diff.added {
+		ui.Output(fmt.Sprintf("  + %s", key))
+	}
+	for _, key := range diff.removed {
+		ui.Output(fmt.Sprintf("  - %s", key))
+	}
+	for _, key := range diff.changed {
+		ui.Output(fmt.Sprintf("  ~ %s", key))
+	}
+	return false
+}
+
+// keyDiff lists the keys that were added, removed or changed in PhraseApp
+// when compared to a local file.
+type keyDiff struct {
+	added   []string
+	removed []string
+	changed []string
+}
+
+func (d *keyDiff) empty() bool {
+	return len(d.added) == 0 && len(d.removed) == 0 && len(d.changed) == 0
+}
+
+func diffKeys(local, remote map[string]string) *keyDiff {
+	diff := new(keyDiff)
+	for key, value := range remote {
+		if localValue, ok := local[key]; !ok {
+			diff.added = append(diff.added, key)
+		} else if localValue != value {
+			diff.changed = append(diff.changed, key)
+		}
+	}
+	for key := range local {
+		if _, ok := remote[key]; !ok {
+			diff.removed = append(diff.removed, key)
+		}
+	}
+	sort.Strings(diff.added)
+	sort.Strings(diff.removed)
+	sort.Strings(diff.changed)
+	return diff
+}
+
+// Help displays available options for the status command.
+func (c *StatusCommand) Help() string {
+	helpText := `
+	Usage: phrase status [options] [LOCALE]
+
+	  Compare the local locale files with the translations in PhraseApp, the
+	  files of the targets configured in .phrase if there are any, unless
+	  --format or --target is given.
+	  Keys are listed as added (+) when they only exist in PhraseApp,
+	  removed (-) when they only exist locally, and changed (~) when their
+	  translations differ. Exits with a non-zero status when any locale differs.
+
+	Options:
+
+        --format=yml                    See documentation for list of allowed formats
+        --target=./phrase/locales       Target folder where the locale files are stored
+        --tag=foo                       Limit results to a given tag instead of all translations
+        --include-empty-translations    Include empty translations in the result
+        --encoding=utf-8                Convert .strings or .properties with alternate encoding
+        --skip-unverified-translations  Skip unverified translations in the result
+        --concurrency=2                 Number of locales to download at the same time
+        --secret=YOUR_AUTH_TOKEN        The Auth Token to use for this operation instead of the saved one (optional)
+	`
+	return strings.TrimSpace(helpText)
+}
+
+// Synopsis displays a synopsis of the status command.
+func (c *StatusCommand) Synopsis() string {
+	return "Compare the local locale files with PhraseApp"
+}
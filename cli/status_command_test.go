@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"fmt"
+	mcli "github.com/mitchellh/cli"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStatusCommand_Help(t *testing.T) {
+	c := StatusCommand{}
+	if c.Help() == "" {
+		t.Fatal("Help should not be empty")
+	}
+}
+
+func TestStatusCommand_Synopsis(t *testing.T) {
+	c := StatusCommand{}
+	if c.Synopsis() == "" {
+		t.Fatal("Synopsis should not be empty")
+	}
+}
+
+func TestStatusCommand_unrecognizedFormat(t *testing.T) {
+	ui := new(mcli.MockUi)
+
+	c := &StatusCommand{UI: ui, Config: new(Config), API: client}
+	code := c.Run([]string{"--format=blah"})
+
+	if code == 0 {
+		t.Fatal("Status command should return code != 0")
+	}
+	if err := ui.ErrorWriter.String(); strings.Index(err, "Unrecognized format: blah") == -1 {
+		t.Fatal("UI should display error message")
+	}
+}
+
+func setupStatusAPI(translations map[string]string) {
+	setupAPI()
+	mux.HandleFunc("/translations/download", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("X-Rate-Limit-Remaining", "59")
+		fmt.Fprint(w, translations[r.URL.Query().Get("locale")])
+	})
+	mux.HandleFunc("/locales", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":1,"name":"en","is_default":true},{"id":2,"name":"ms"}]`)
+	})
+}
+
+func TestStatusCommand_upToDate(t *testing.T) {
+	setupStatusAPI(map[string]string{
+		"en": "en:\n  hello: Hello\n",
+		"ms": "ms:\n  hello: Halo\n",
+	})
+	defer tearDown()
+
+	createTestFiles(map[string][]byte{
+		"phrase.en.yml": []byte("en:\n  hello: Hello\n"),
+		"phrase.ms.yml": []byte("ms:\n  hello: 'Halo'\n"),
+	})
+
+	ui := new(mcli.MockUi)
+	c := &StatusCommand{UI: ui, Config: new(Config), API: client}
+	code := c.Run([]string{"--target=./test"})
+
+	if code != 0 {
+		t.Fatalf("Status command should return code == 0, got %s", ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); strings.Count(out, "is up to date") != 2 {
+		t.Errorf("Status command should report both locales as up to date, got %q", out)
+	}
+}
+
+func TestStatusCommand_targets(t *testing.T) {
+	setupStatusAPI(map[string]string{
+		"en": "en:\n  hello: Hello\n",
+		"ms": "ms:\n  hello: Halo\n",
+	})
+	defer tearDown()
+
+	prepareLocaleFiles(map[string][]byte{"en.yml": []byte("en:\n  hello: Hello\n")}, testFolder, "web")
+
+	ui := new(mcli.MockUi)
+	config := &Config{Targets: []*TargetConfig{{Format: "yml", File: filepath.Join(testFolder, "web", "<locale.name>.yml"), Locales: []string{"en"}}}}
+	c := &StatusCommand{UI: ui, Config: config, API: client}
+	if code := c.Run([]string{}); code != 0 {
+		t.Fatalf("Status command should return code == 0, got %s", ui.ErrorWriter.String())
+	}
+	if out, want := ui.OutputWriter.String(), filepath.Join(testFolder, "web", "en.yml")+" is up to date"; !strings.Contains(out, want) || strings.Contains(out, "ms:") {
+		t.Errorf("Status command should compare the files of the targets, expected %q, got %q", want, out)
+	}
+}
+
+func TestStatusCommand_drift(t *testing.T) {
+	setupStatusAPI(map[string]string{
+		"en": "en:\n  hello: Hello\n  added: Added\n  changed: New\n",
+	})
+	defer tearDown()
+
+	createTestFiles(map[string][]byte{
+		"phrase.en.yml": []byte("en:\n  hello: Hello\n  removed: Removed\n  changed: Old\n"),
+	})
+
+	ui := new(mcli.MockUi)
+	c := &StatusCommand{UI: ui, Config: new(Config), API: client}
+	code := c.Run([]string{"--target=./test", "en"})
+
+	if code == 0 {
+		t.Fatal("Status command should return code != 0")
+	}
+	if err := ui.ErrorWriter.String(); strings.Index(err, "1 added, 1 removed and 1 changed keys") == -1 {
+		t.Errorf("Status command should display a summary of the differences, got %q", err)
+	}
+	if want, out := "  + added\n  - removed\n  ~ changed\n", ui.OutputWriter.String(); out != want {
+		t.Errorf("Status command output expected %q, got %q", want, out)
+	}
+}
+
+func TestStatusCommand_unknownLocale(t *testing.T) {
+	setupStatusAPI(map[string]string{
+		"en": "en:\n  hello: Hello\n",
+	})
+	defer tearDown()
+
+	createTestFiles(map[string][]byte{
+		"phrase.en.yml": []byte("en:\n  hello: Hello\n"),
+	})
+
+	ui := new(mcli.MockUi)
+	c := &StatusCommand{UI: ui, Config: new(Config), API: client}
+	if code := c.Run([]string{"--target=./test", "en", "xx"}); code == 0 {
+		t.Fatal("Status command should return code != 0 for unknown locales")
+	}
+	if err := ui.ErrorWriter.String(); strings.Index(err, "unknown locale xx") == -1 {
+		t.Errorf("Status command should report unknown locales, got %q", err)
+	}
+	if out := ui.OutputWriter.String(); strings.Index(out, "is up to date") == -1 {
+		t.Errorf("Status command should still compare the known locales, got %q", out)
+	}
+}
+
+func TestStatusCommand_missingFile(t *testing.T) {
+	setupStatusAPI(map[string]string{})
+	defer tearDown()
+
+	ui := new(mcli.MockUi)
+	c := &StatusCommand{UI: ui, Config: new(Config), API: client}
+	code := c.Run([]string{"--target=./test", "ms"})
+
+	if code == 0 {
+		t.Fatal("Status command should return code != 0")
+	}
+	if err := ui.ErrorWriter.String(); strings.Index(err, "is missing") == -1 {
+		t.Errorf("Status command should report missing files, got %q", err)
+	}
+}
+
+func TestStatusCommand_unparsedFormat(t *testing.T) {
+	setupStatusAPI(map[string]string{
+		"en": "hello=Hello",
+	})
+	defer tearDown()
+
+	createTestFiles(map[string][]byte{
+		"phrase.en.ini": []byte("hello=Hi"),
+	})
+
+	ui := new(mcli.MockUi)
+	c := &StatusCommand{UI: ui, Config: new(Config), API: client}
+	code := c.Run([]string{"--target=./test", "--format=ini", "en"})
+
+	if code == 0 {
+		t.Fatal("Status command should return code != 0")
+	}
+	if err := ui.ErrorWriter.String(); strings.Index(err, "differs from PhraseApp") == -1 {
+		t.Errorf("Status command should report files that differ, got %q", err)
+	}
+}
+
+func TestDiffKeys(t *testing.T) {
+	diff := diffKeys(
+		map[string]string{"a": "1", "b": "2", "c": "3"},
+		map[string]string{"a": "1", "b": "x", "d": "4"},
+	)
+	if want := []string{"d"}; !reflect.DeepEqual(diff.added, want) {
+		t.Errorf("diffKeys added expected %v, got %v", want, diff.added)
+	}
+	if want := []string{"c"}; !reflect.DeepEqual(diff.removed, want) {
+		t.Errorf("diffKeys removed expected %v, got %v", want, diff.removed)
+	}
+	if want := []string{"b"}; !reflect.DeepEqual(diff.changed, want) {
+		t.Errorf("diffKeys changed expected %v, got %v", want, diff.changed)
+	}
+}
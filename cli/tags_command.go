@@ -25,6 +25,10 @@ func (c *TagsCommand) Run(args []string) int {
 	if err := cmdFlags.Parse(args); err != nil {
 		return 1
 	}
+	if err := c.Config.loadSecret(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
 	c.API.AuthToken = c.Config.Secret
 	tags, err := c.API.Tags.ListAll()
 	if err != nil {
@@ -3,10 +3,12 @@ package cli
 import (
 	"bytes"
 	"fmt"
+	mcli "github.com/mitchellh/cli"
 	"github.com/weynsee/go-phrase/phrase"
+	"path"
+	"path/filepath"
+	"regexp"
 	"strings"
-	"unicode/utf16"
-	"unicode/utf8"
 )
 
 func replacePlaceholders(s string, c *Config, l *phrase.Locale) string {
@@ -20,6 +22,100 @@ func replacePlaceholders(s string, c *Config, l *phrase.Locale) string {
 	return replace(s, translations)
 }
 
+var placeholderFormat = regexp.MustCompile(`<(?:domain|format|locale\.name|locale\.code|locale)>`)
+
+// matchPlaceholders reverses replacePlaceholders: it returns the values of
+// the placeholders of the directory and filename patterns in file, or nil
+// if file does not match them. The directory pattern matches the end of the
+// directory of file, an empty pattern matches any directory or filename.
+// Placeholders the config has a value for, like <domain>, only match that
+// value. c may be nil.
+func matchPlaceholders(directory, filename, file string, c *Config) map[string]string {
+	var names []string
+	expr := "(?:^|/)"
+	if directory = strings.Trim(path.Clean("/"+filepath.ToSlash(directory)), "/"); directory != "" {
+		expr += placeholderExpr(directory, c, &names) + "/"
+	}
+	if filename == "" {
+		expr += "[^/]+$"
+	} else {
+		expr += placeholderExpr(filename, c, &names) + "$"
+	}
+	res := regexp.MustCompile(expr).FindStringSubmatch(filepath.ToSlash(file))
+	if res == nil {
+		return nil
+	}
+	values := make(map[string]string)
+	for i, name := range names {
+		// placeholders used more than once must have the same value
+		if value, ok := values[name]; ok && value != res[i+1] {
+			return nil
+		}
+		values[name] = res[i+1]
+	}
+	return values
+}
+
+// placeholderExpr returns a regular expression for pattern, with a group
+// for every placeholder whose name is appended to names. Patterns may use
+// the wildcards of glob patterns.
+func placeholderExpr(pattern string, c *Config, names *[]string) string {
+	var buf bytes.Buffer
+	last := 0
+	for _, loc := range placeholderFormat.FindAllStringIndex(pattern, -1) {
+		buf.WriteString(globExpr(pattern[last:loc[0]]))
+		name := pattern[loc[0]:loc[1]]
+		var value string
+		if c != nil && name == "<domain>" {
+			value = c.Domain
+		} else if c != nil && name == "<format>" {
+			value = c.Format
+		}
+		if value != "" {
+			buf.WriteString(regexp.QuoteMeta(value))
+		} else {
+			buf.WriteString("([^/]+?)")
+			*names = append(*names, name)
+		}
+		last = loc[1]
+	}
+	buf.WriteString(globExpr(pattern[last:]))
+	return buf.String()
+}
+
+// globExpr returns a regular expression for s, in which * and ? match like
+// in glob patterns.
+func globExpr(s string) string {
+	return strings.NewReplacer(`\*`, `[^/]*`, `\?`, `[^/]`).Replace(regexp.QuoteMeta(s))
+}
+
+// localeFromPlaceholders returns the locale name matched by
+// matchPlaceholders, or its code if the pattern only has the code, in which
+// case it returns true.
+func localeFromPlaceholders(values map[string]string) (string, bool) {
+	for _, name := range []string{"<locale.name>", "<locale>"} {
+		if values[name] != "" {
+			return values[name], false
+		}
+	}
+	return values["<locale.code>"], values["<locale.code>"] != ""
+}
+
+// localeNameForCode returns the name of the locale of the project with the
+// given code, or the code if there is none.
+func localeNameForCode(c *phrase.Client, code string) (string, error) {
+	locales, err := c.Locales.ListAll()
+	if err != nil {
+		return "", err
+	}
+	for _, locale := range locales {
+		if locale.Code == code {
+			return locale.Name, nil
+		}
+	}
+	return code, nil
+}
+
 func replace(s string, translations map[string]string) string {
 	if s == "" {
 		return s
@@ -43,28 +139,45 @@ func findDefaultLocaleName(c *phrase.Client) (string, error) {
 	return "", nil
 }
 
+// selectLocales returns the locales of the project with the given names,
+// or all locales if no names are given. Unknown locales are skipped.
+func selectLocales(api *phrase.Client, ui mcli.Ui, locales []string) ([]phrase.Locale, error) {
+	all, err := api.Locales.ListAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(locales) == 0 {
+		return all, nil
+	}
+	localeMap := make(map[string]phrase.Locale)
+	for _, locale := range all {
+		localeMap[locale.Name] = locale
+	}
+	var selected = make([]phrase.Locale, 0, len(locales))
+	for _, locale := range locales {
+		if l, ok := localeMap[locale]; ok {
+			selected = append(selected, l)
+		} else {
+			ui.Warn(fmt.Sprintf("Skipping unknown locale %s", locale))
+		}
+	}
+	return selected, nil
+}
+
+// isUTF16 returns whether b starts with a UTF-16 byte order mark.
 func isUTF16(b []byte) bool {
-	return (b[0] == 0xfe && b[1] == 0xff) || (b[0] == 0xff && b[1] == 0xfe)
+	return bytes.HasPrefix(b, bomUTF16LE) || bytes.HasPrefix(b, bomUTF16BE)
 }
 
+// decodeUTF16 decodes UTF-16 text in the byte order indicated by its byte
+// order mark, which is dropped. Text without one is read as little-endian.
 func decodeUTF16(b []byte) (string, error) {
 	if len(b)%2 != 0 {
 		return "", fmt.Errorf("Must have even length byte slice")
 	}
-
-	u16s := make([]uint16, 1)
-
-	ret := &bytes.Buffer{}
-
-	b8buf := make([]byte, 4)
-
-	lb := len(b)
-	for i := 0; i < lb; i += 2 {
-		u16s[0] = uint16(b[i]) + (uint16(b[i+1]) << 8)
-		r := utf16.Decode(u16s)
-		n := utf8.EncodeRune(b8buf, r[0])
-		ret.Write(b8buf[:n])
+	bigEndian := bytes.HasPrefix(b, bomUTF16BE)
+	if isUTF16(b) {
+		b = b[2:]
 	}
-
-	return ret.String(), nil
+	return decodeUTF16WithoutBOM(b, bigEndian)
 }
@@ -17,6 +17,38 @@ func TestUtils_replace(t *testing.T) {
 	}
 }
 
+func TestUtils_matchPlaceholders(t *testing.T) {
+	tests := []struct {
+		directory, filename, file string
+		want                      map[string]string
+	}{
+		{"./<locale.name>/", "<domain>.po", "locales/fr/app.po", map[string]string{"<locale.name>": "fr", "<domain>": "app"}},
+		{"./", "phrase.<locale.code>.yml", "phrase.pt-BR.yml", map[string]string{"<locale.code>": "pt-BR"}},
+		{"config/locales", "<locale>.yml", "./config/locales/de.yml", map[string]string{"<locale>": "de"}},
+		{"<locale.name>", "<locale.name>.json", "i18n/es/es.json", map[string]string{"<locale.name>": "es"}},
+		{"", "", "anything.txt", map[string]string{}},
+		{"<locale.name>", "<locale.name>.json", "i18n/es/fr.json", nil},
+		{"./", "phrase.<locale.name>.yml", "en.yml", nil},
+		{"lang/<locale.name>", "", "other/fr/messages.xml", nil},
+	}
+	for _, test := range tests {
+		got := matchPlaceholders(test.directory, test.filename, test.file, nil)
+		if fmt.Sprint(got) != fmt.Sprint(test.want) || (got == nil) != (test.want == nil) {
+			t.Errorf("matchPlaceholders(%q, %q, %q) expected %v, got %v", test.directory, test.filename, test.file, test.want, got)
+		}
+	}
+}
+
+func TestUtils_matchPlaceholdersConfig(t *testing.T) {
+	c := &Config{Domain: "app"}
+	if got := matchPlaceholders("<locale.name>", "<domain>.po", "fr/app.po", c); got["<locale.name>"] != "fr" {
+		t.Errorf("matchPlaceholders should match the configured domain, got %v", got)
+	}
+	if got := matchPlaceholders("<locale.name>", "<domain>.po", "fr/other.po", c); got != nil {
+		t.Errorf("matchPlaceholders should only match the configured domain, got %v", got)
+	}
+}
+
 func TestUtils_findDefaultLocaleNameError(t *testing.T) {
 	setupAPI()
 	defer shutdownAPI()
@@ -85,3 +117,21 @@ func TestUtils_decodeUTF16Error(t *testing.T) {
 		t.Error("decodeUTF16 should return error for invalid length byte slice")
 	}
 }
+
+func TestUtils_isUTF16Short(t *testing.T) {
+	for _, b := range [][]byte{nil, {0xff}} {
+		if isUTF16(b) {
+			t.Errorf("isUTF16(%v) should be false", b)
+		}
+	}
+}
+
+func TestUtils_decodeUTF16BigEndian(t *testing.T) {
+	s, err := decodeUTF16([]byte{0xfe, 0xff, 0, 'T', 0x34, 0x6c})
+	if err != nil {
+		t.Fatalf("decodeUTF16 encountered error %+v", err.Error())
+	}
+	if s != "T㑬" {
+		t.Errorf("decodeUTF16 expected %q, got %q", "T㑬", s)
+	}
+}
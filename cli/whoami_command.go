@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"fmt"
+	mcli "github.com/mitchellh/cli"
+	"github.com/weynsee/go-phrase/phrase"
+	"strings"
+)
+
+// WhoamiCommand will display the user signed in by login.
+type WhoamiCommand struct {
+	UI     mcli.Ui
+	Config *Config
+	API    *phrase.Client
+}
+
+// Run executes the whoami command.
+func (c *WhoamiCommand) Run(args []string) int {
+	if len(args) > 0 {
+		c.UI.Output(c.Help())
+		return 1
+	}
+	if err := c.Config.signIn(c.API); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	user, err := c.API.Sessions.CheckLogin()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error encountered while checking the session: %s", err.Error()))
+		return 1
+	}
+	if user == nil {
+		c.UI.Error("The session expired, please run go-phrase login")
+		return 1
+	}
+	c.UI.Output(fmt.Sprintf("Name:  %s", user.Name))
+	c.UI.Output(fmt.Sprintf("Email: %s", user.Email))
+	c.UI.Output(fmt.Sprintf("Role:  %s", user.Role))
+	return 0
+}
+
+// Help displays available options for the whoami command.
+func (c *WhoamiCommand) Help() string {
+	helpText := `
+	Usage: phrase whoami
+
+	  Display the name, email and role of the user signed in by login.
+	`
+	return strings.TrimSpace(helpText)
+}
+
+// Synopsis displays a synopsis of the whoami command.
+func (c *WhoamiCommand) Synopsis() string {
+	return "Display the user signed in to PhraseApp"
+}
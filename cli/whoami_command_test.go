@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"fmt"
+	mcli "github.com/mitchellh/cli"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestWhoamiCommand_Run(t *testing.T) {
+	setupAPI()
+	defer tearDown()
+
+	new(credentialsFile).store(sessionCredential, "usertoken")
+	mux.HandleFunc("/auth/check_login", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("auth_token") != "usertoken" || r.FormValue("project_auth_token") != "projecttoken" {
+			t.Errorf("Whoami should send signed requests, got %v", r.Form)
+		}
+		fmt.Fprint(w, `{"logged_in":true,"user":{"id":1,"name":"Jo Doe","email":"jo@example.com","role_name":"Manager"}}`)
+	})
+
+	ui := new(mcli.MockUi)
+	c := &WhoamiCommand{UI: ui, Config: &Config{Secret: "projecttoken"}, API: client}
+	if code := c.Run([]string{}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	want := "Name:  Jo Doe\nEmail: jo@example.com\nRole:  Manager\n"
+	if out := ui.OutputWriter.String(); out != want {
+		t.Errorf("Whoami should display the user, expected %q, got %q", want, out)
+	}
+}
+
+func TestWhoamiCommand_notLoggedIn(t *testing.T) {
+	ui := new(mcli.MockUi)
+	c := &WhoamiCommand{UI: ui, Config: new(Config)}
+	if code := c.Run([]string{}); code == 0 {
+		t.Fatal("Whoami without a session should fail")
+	}
+	if err := ui.ErrorWriter.String(); strings.Index(err, "Not logged in") == -1 {
+		t.Errorf("UI should display error message, got %q", err)
+	}
+}
+
+func TestWhoamiCommand_Help(t *testing.T) {
+	c := WhoamiCommand{}
+	if c.Help() == "" {
+		t.Fatal("Help should not be empty")
+	}
+}
+
+func TestWhoamiCommand_Synopsis(t *testing.T) {
+	c := WhoamiCommand{}
+	if c.Synopsis() == "" {
+		t.Fatal("Synopsis should not be empty")
+	}
+}
@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	mcli "github.com/mitchellh/cli"
+	"github.com/weynsee/go-phrase/phrase"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// XliffCommand will split XLIFF documents into one file per locale, or
+// merge per-locale files into one document, e.g. for hand-offs to
+// translation agencies.
+type XliffCommand struct {
+	UI     mcli.Ui
+	Config *Config
+}
+
+// Run executes the xliff command.
+func (c *XliffCommand) Run(args []string) int {
+	cmdFlags := flag.NewFlagSet("xliff", flag.ContinueOnError)
+	cmdFlags.Usage = func() { c.UI.Output(c.Help()) }
+
+	config := *c.Config
+	config.Format = "xlf"
+	cmdFlags.StringVar(&config.TargetDirectory, "target", config.TargetDirectory, "")
+	var output string
+	cmdFlags.StringVar(&output, "output", "", "")
+
+	if len(args) == 0 {
+		c.UI.Output(c.Help())
+		return 1
+	}
+	action := args[0]
+	if err := cmdFlags.Parse(args[1:]); err != nil {
+		return 1
+	}
+	files := cmdFlags.Args()
+	if len(files) == 0 {
+		c.UI.Error("No XLIFF files given")
+		return 1
+	}
+
+	switch action {
+	case "split":
+		return c.split(&config, files)
+	case "merge":
+		if output == "" {
+			c.UI.Error("--output is required to merge XLIFF files")
+			return 1
+		}
+		return c.merge(output, files)
+	}
+	c.UI.Error(fmt.Sprintf("Unknown action %s, expected split or merge", action))
+	return 1
+}
+
+// read returns the catalogs of all the files of the XLIFF documents.
+func (c *XliffCommand) read(files []string) ([]*catalog, bool) {
+	var cats []*catalog
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err == nil {
+			var found []*catalog
+			found, err = decodeXliffFiles(b)
+			cats = append(cats, found...)
+		}
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error reading %s:\n\t%s", file, err.Error()))
+			return nil, false
+		}
+	}
+	return cats, true
+}
+
+// split writes the files of the XLIFF documents into one document per
+// locale, at the path the xlf format uses for the locale.
+func (c *XliffCommand) split(config *Config, files []string) int {
+	cats, ok := c.read(files)
+	if !ok {
+		return 1
+	}
+
+	// files of the same locale end up in the same document
+	var locales []string
+	byLocale := make(map[string][]*catalog)
+	for _, cat := range cats {
+		if cat.Locale == "" {
+			c.UI.Error("Cannot split XLIFF files without a target language")
+			return 1
+		}
+		if _, ok := byLocale[cat.Locale]; !ok {
+			locales = append(locales, cat.Locale)
+		}
+		byLocale[cat.Locale] = append(byLocale[cat.Locale], cat)
+	}
+
+	status := 0
+	for _, locale := range locales {
+		lc := config.ForLocale(&phrase.Locale{Name: locale, Code: locale})
+		folder, path := localePath(lc)
+		b, err := encodeXliffFiles(byLocale[locale])
+		if err == nil {
+			err = os.MkdirAll(folder, 0777)
+		}
+		if err == nil {
+			_, err = writeFileAtomic(path, b)
+		}
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error writing file %s:\n\t%s", path, err.Error()))
+			status = 1
+			continue
+		}
+		c.UI.Output(fmt.Sprintf("Wrote %s", path))
+	}
+	return status
+}
+
+// merge writes the files of the XLIFF documents into a single document.
+func (c *XliffCommand) merge(output string, files []string) int {
+	cats, ok := c.read(files)
+	if !ok {
+		return 1
+	}
+	b, err := encodeXliffFiles(cats)
+	if err == nil {
+		_, err = writeFileAtomic(output, b)
+	}
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error writing file %s:\n\t%s", output, err.Error()))
+		return 1
+	}
+	c.UI.Output(fmt.Sprintf("Wrote %s", output))
+	return 0
+}
+
+// Help displays available options for the xliff command.
+func (c *XliffCommand) Help() string {
+	helpText := `
+	Usage: phrase xliff split [options] FILE...
+	       phrase xliff merge --output=FILE FILE...
+
+	  Split XLIFF documents into one file per locale, or merge XLIFF files into one document.
+
+	Options:
+
+	  --target=./               Target folder to store the split locale files
+	  --output=FILE             The file to write the merged document to
+	`
+	return strings.TrimSpace(helpText)
+}
+
+// Synopsis displays a synopsis of the xliff command.
+func (c *XliffCommand) Synopsis() string {
+	return "Split or merge XLIFF files for translation hand-offs"
+}
@@ -0,0 +1,83 @@
+package cli
+
+import (
+	mcli "github.com/mitchellh/cli"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const xliffBundle = `<xliff version="1.2">
+<file source-language="en" target-language="de"><body><trans-unit id="a"><source>A</source><target>Ä</target></trans-unit></body></file>
+<file source-language="en" target-language="fr"><body><trans-unit id="b"><source>B</source><target>Bé</target></trans-unit></body></file>
+</xliff>`
+
+func xliffTestFile(t *testing.T, path string) []byte {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Could not read %s: %v", path, err)
+	}
+	return b
+}
+
+func TestXliffCommand_split(t *testing.T) {
+	defer os.RemoveAll(testFolder)
+	prepareLocaleFiles(map[string][]byte{"bundle.xlf": []byte(xliffBundle)}, testFolder)
+
+	ui := new(mcli.MockUi)
+	c := &XliffCommand{UI: ui, Config: new(Config)}
+	if code := c.Run([]string{"split", "--target=" + testFolder, filepath.Join(testFolder, "bundle.xlf")}); code != 0 {
+		t.Fatalf("Xliff command should succeed, got %d: %s", code, ui.ErrorWriter.String())
+	}
+	for _, locale := range []string{"de", "fr"} {
+		path := filepath.Join(testFolder, "phrase."+locale+".xlf")
+		if out := ui.OutputWriter.String(); !strings.Contains(out, "Wrote "+path) {
+			t.Errorf("Xliff command should report %s, got %q", path, out)
+		}
+		cats, err := decodeXliffFiles(xliffTestFile(t, path))
+		if err != nil || len(cats) != 1 || cats[0].Locale != locale {
+			t.Errorf("Xliff command should write only %s to %s, got %+v (%v)", locale, path, cats, err)
+		}
+	}
+}
+
+func TestXliffCommand_merge(t *testing.T) {
+	defer os.RemoveAll(testFolder)
+	prepareLocaleFiles(map[string][]byte{
+		"de.xlf": []byte(`<xliff version="1.2"><file target-language="de"><body><trans-unit id="a"><source>A</source></trans-unit></body></file></xliff>`),
+		"fr.xlf": []byte(`<xliff version="1.2"><file target-language="fr"><body><trans-unit id="a"><source>A</source></trans-unit></body></file></xliff>`),
+	}, testFolder)
+
+	ui := new(mcli.MockUi)
+	c := &XliffCommand{UI: ui, Config: new(Config)}
+	output := filepath.Join(testFolder, "bundle.xlf")
+	args := []string{"merge", "--output=" + output, filepath.Join(testFolder, "de.xlf"), filepath.Join(testFolder, "fr.xlf")}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("Xliff command should succeed, got %d: %s", code, ui.ErrorWriter.String())
+	}
+	cats, err := decodeXliffFiles(xliffTestFile(t, output))
+	if err != nil || len(cats) != 2 || cats[1].Locale != "fr" {
+		t.Errorf("Xliff command should merge the files, got %+v (%v)", cats, err)
+	}
+}
+
+func TestXliffCommand_errors(t *testing.T) {
+	tests := map[string][]string{
+		"Unknown action":       {"join", "a.xlf"},
+		"No XLIFF files":       {"split"},
+		"--output is required": {"merge", "a.xlf"},
+		"Error reading":        {"split", filepath.Join(testFolder, "missing.xlf")},
+	}
+	for want, args := range tests {
+		ui := new(mcli.MockUi)
+		c := &XliffCommand{UI: ui, Config: new(Config)}
+		if code := c.Run(args); code != 1 {
+			t.Errorf("Xliff command %v should fail, got %d", args, code)
+		}
+		if out := ui.ErrorWriter.String(); !strings.Contains(out, want) {
+			t.Errorf("Xliff command %v expected error %q, got %q", args, want, out)
+		}
+	}
+}